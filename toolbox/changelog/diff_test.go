@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestDiffFiles(t *testing.T) {
+	tables := []struct {
+		old     string
+		updated string
+		want    string
+	}{
+		{"a\n", "a\n", ""},
+		{"a\nb\n", "a\nc\n", "--- a/CHANGELOG-1.8.md\n+++ b/CHANGELOG-1.8.md\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n"},
+		{"", "a\n", "--- a/CHANGELOG-1.8.md\n+++ b/CHANGELOG-1.8.md\n@@ -0,0 +1 @@\n+a\n"},
+		{"a\n", "a", "--- a/CHANGELOG-1.8.md\n+++ b/CHANGELOG-1.8.md\n@@ -1 +1 @@\n-a\n+a\n\\ No newline at end of file\n"},
+		// Changes more than 6 lines apart get their own hunks
+		{
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n12\n",
+			"--- a/CHANGELOG-1.8.md\n+++ b/CHANGELOG-1.8.md\n" +
+				"@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n" +
+				"@@ -8,5 +9,4 @@\n 8\n 9\n 10\n-11\n 12\n",
+		},
+		// Closer changes share a hunk
+		{
+			"1\n2\n3\n4\n5\n6\n7\n8\n",
+			"1\nx\n3\n4\n5\n6\n7\ny\n",
+			"--- a/CHANGELOG-1.8.md\n+++ b/CHANGELOG-1.8.md\n" +
+				"@@ -1,8 +1,8 @@\n 1\n-2\n+x\n 3\n 4\n 5\n 6\n 7\n-8\n+y\n",
+		},
+	}
+
+	for _, table := range tables {
+		if got := diffFiles("CHANGELOG-1.8.md", table.old, table.updated); got != table.want {
+			t.Errorf("%q to %q: Diff was incorrect, want:\n%s\ngot:\n%s", table.old, table.updated, table.want, got)
+		}
+	}
+}
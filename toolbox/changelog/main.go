@@ -0,0 +1,337 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	u "k8s.io/release/toolbox/util"
+)
+
+const (
+	tocBegin = "<!-- BEGIN MUNGE: GENERATED_TOC -->"
+	tocEnd   = "<!-- END MUNGE: GENERATED_TOC -->"
+)
+
+var (
+	// Flags
+	changelogFile = flag.String("changelog-file", "", "CHANGELOG file to update. Defaults to CHANGELOG-X.Y.md of the notes version in --repo-dir")
+	dryRun        = flag.Bool("dry-run", false, "Print the diff of the CHANGELOG file instead of writing it")
+	repoDir       = flag.String("repo-dir", ".", "Local clone of the repository containing the CHANGELOG files")
+
+	// releaseHeading matches the heading starting the notes of a release, e.g. "# v1.8.1".
+	releaseHeading = regexp.MustCompile("^# (v[0-9]+\\.[0-9]+\\.[0-9]+(-[0-9A-Za-z.-]+)?)\\s*$")
+	// heading matches markdown headings, which are listed in the TOC.
+	heading = regexp.MustCompile("^(#+)\\s+(.*?)\\s*$")
+	// fence matches the opening or closing line of a fenced code block.
+	fence = regexp.MustCompile("^\\s*(`{3,}|~{3,})")
+	// semver matches the versions of releases, e.g. "v1.8.0-rc.1".
+	semver = regexp.MustCompile("^v([0-9]+)\\.([0-9]+)\\.([0-9]+)(?:-([0-9A-Za-z.-]+))?$")
+)
+
+// Changelog is a parsed CHANGELOG file.
+type Changelog struct {
+	// Header is the content before the first release: the TOC and the new release notes marker
+	Header string
+	// Releases are the release notes, newest first
+	Releases []Release
+}
+
+// Release is the notes of a single release in a CHANGELOG file.
+type Release struct {
+	Version string
+	// Content starts with the "# vX.Y.Z" heading of the release
+	Content string
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] release-notes.md\n\n", os.Args[0])
+		fmt.Fprint(os.Stderr, "Inserts, or replaces, the relnotes markdown output in its CHANGELOG file.\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	notes, err := ioutil.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Printf("failed to read release notes: %v", err)
+		os.Exit(1)
+	}
+	release, err := parseNotes(string(notes))
+	if err != nil {
+		log.Printf("failed to parse release notes %s: %v", flag.Arg(0), err)
+		os.Exit(1)
+	}
+
+	filename := *changelogFile
+	if filename == "" {
+		if filename, err = changelogFileName(release.Version); err != nil {
+			log.Print(err)
+			os.Exit(1)
+		}
+		filename = filepath.Join(*repoDir, filename)
+	}
+
+	old := u.ChangelogStub
+	content, err := ioutil.ReadFile(filename)
+	if err == nil {
+		old = string(content)
+	} else if !os.IsNotExist(err) {
+		log.Printf("failed to read %s: %v", filename, err)
+		os.Exit(1)
+	}
+
+	c := parseChangelog(old)
+	c.Update(release)
+	updated := c.String()
+
+	if *dryRun {
+		fmt.Print(diffFiles(filename, old, updated))
+		return
+	}
+	if updated == old && content != nil {
+		log.Printf("%s already contains the %s notes, nothing to do.", filename, release.Version)
+		return
+	}
+	if err = ioutil.WriteFile(filename, []byte(updated), 0644); err != nil {
+		log.Printf("failed to write %s: %v", filename, err)
+		os.Exit(1)
+	}
+	log.Printf("Updated the %s notes in %s.", release.Version, filename)
+}
+
+// changelogFileName returns the name of the CHANGELOG file containing the notes of input
+// version, e.g. "CHANGELOG-1.8.md" for "v1.8.1".
+func changelogFileName(version string) (string, error) {
+	v := semver.FindStringSubmatch(version)
+	if v == nil {
+		return "", fmt.Errorf("invalid version %q", version)
+	}
+	return fmt.Sprintf("CHANGELOG-%s.%s.md", v[1], v[2]), nil
+}
+
+// parseNotes parses the markdown notes of a single release, as generated by relnotes. Anything
+// before the release heading, e.g. a preview banner, is dropped.
+func parseNotes(notes string) (Release, error) {
+	c := parseChangelog(notes)
+	if len(c.Releases) != 1 {
+		return Release{}, fmt.Errorf("want the notes of one release, got %d", len(c.Releases))
+	}
+	return c.Releases[0], nil
+}
+
+// parseChangelog splits input CHANGELOG content into its header and releases. Release headings
+// in fenced code blocks are ignored.
+func parseChangelog(content string) *Changelog {
+	c := &Changelog{}
+	var b bytes.Buffer
+	flush := func() {
+		if len(c.Releases) == 0 {
+			c.Header = b.String()
+		} else {
+			c.Releases[len(c.Releases)-1].Content = b.String()
+		}
+		b.Reset()
+	}
+
+	inFence := ""
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if m := fence.FindStringSubmatch(line); m != nil {
+			if inFence == "" {
+				inFence = m[1]
+			} else if m[1][0] == inFence[0] && len(m[1]) >= len(inFence) {
+				inFence = ""
+			}
+		} else if m := releaseHeading.FindStringSubmatch(strings.TrimRight(line, "\n")); m != nil && inFence == "" {
+			flush()
+			c.Releases = append(c.Releases, Release{Version: m[1]})
+		}
+		b.WriteString(line)
+	}
+	flush()
+	return c
+}
+
+// Update inserts input release in its semver position, or replaces it if the CHANGELOG already
+// contains it.
+func (c *Changelog) Update(r Release) {
+	// Releases are separated by two blank lines, as changelog-update does
+	r.Content = strings.TrimRight(r.Content, "\n") + "\n\n\n"
+
+	for i, old := range c.Releases {
+		if old.Version == r.Version {
+			c.Releases[i] = r
+			return
+		}
+	}
+	i := 0
+	for i < len(c.Releases) && compareVersions(c.Releases[i].Version, r.Version) > 0 {
+		i++
+	}
+	c.Releases = append(c.Releases, Release{})
+	copy(c.Releases[i+1:], c.Releases[i:])
+	c.Releases[i] = r
+}
+
+// String renders the CHANGELOG with a regenerated TOC.
+func (c *Changelog) String() string {
+	var body bytes.Buffer
+	for _, r := range c.Releases {
+		body.WriteString(r.Content)
+	}
+
+	header := c.Header
+	if len(c.Releases) > 0 {
+		// Keep a blank line between the new release notes marker and the newest release, as anago does
+		header = strings.TrimRight(header, "\n") + "\n\n\n"
+	}
+	return replaceTOC(header, markdownTOC(body.String())) + body.String()
+}
+
+// markdownTOC generates the table of contents of input markdown like common::mdtoc does: one
+// indented link per heading, with "-N" suffixes for duplicated anchors. Anchors follow Github's
+// rules so that the links match the rendered headings. Headings in fenced code blocks are ignored.
+func markdownTOC(markdown string) string {
+	var b bytes.Buffer
+	count := make(map[string]int)
+	inFence := ""
+	for _, line := range strings.Split(markdown, "\n") {
+		if m := fence.FindStringSubmatch(line); m != nil {
+			if inFence == "" {
+				inFence = m[1]
+			} else if m[1][0] == inFence[0] && len(m[1]) >= len(inFence) {
+				inFence = ""
+			}
+			continue
+		}
+		m := heading.FindStringSubmatch(line)
+		if m == nil || inFence != "" {
+			continue
+		}
+
+		anchor := tocAnchor(m[2])
+		if n, ok := count[anchor]; ok {
+			count[anchor] = n + 1
+			anchor += "-" + strconv.Itoa(n+1)
+		} else {
+			count[anchor] = 0
+		}
+		indent := strings.Repeat("  ", len(m[1])-1)
+		b.WriteString(fmt.Sprintf("%s- [%s](#%s)\n", indent, m[2], anchor))
+	}
+	return b.String()
+}
+
+// tocAnchor converts a heading into its anchor the way Github does: lower case, only letters,
+// digits, spaces, dashes and underscores kept, and spaces replaced by dashes.
+func tocAnchor(heading string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '-'
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			return r
+		}
+		return -1
+	}, strings.ToLower(heading))
+}
+
+// replaceTOC replaces the content between the TOC markers of input header with toc. Headers
+// without TOC markers are returned as is.
+func replaceTOC(header, toc string) string {
+	begin := strings.Index(header, tocBegin+"\n")
+	end := strings.Index(header, tocEnd)
+	if begin == -1 || end < begin {
+		return header
+	}
+	return header[:begin+len(tocBegin)+1] + toc + header[end:]
+}
+
+// compareVersions compares two release versions by semver precedence, returning -1, 0 or 1.
+// Versions which aren't semver are ordered before all others.
+func compareVersions(a, b string) int {
+	va, vb := semver.FindStringSubmatch(a), semver.FindStringSubmatch(b)
+	switch {
+	case va == nil && vb == nil:
+		return strings.Compare(a, b)
+	case va == nil:
+		return -1
+	case vb == nil:
+		return 1
+	}
+
+	for i := 1; i <= 3; i++ {
+		if c := compareIdentifiers(va[i], vb[i]); c != 0 {
+			return c
+		}
+	}
+	// A release has higher precedence than its pre-releases
+	switch {
+	case va[4] == vb[4]:
+		return 0
+	case va[4] == "":
+		return 1
+	case vb[4] == "":
+		return -1
+	}
+	pa, pb := strings.Split(va[4], "."), strings.Split(vb[4], ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if c := compareIdentifiers(pa[i], pb[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(pa), len(pb))
+}
+
+// compareIdentifiers compares semver identifiers: numerically if both are numbers, with numbers
+// ordered before words, and lexically otherwise.
+func compareIdentifiers(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// compareInts compares two integers, returning -1, 0 or 1.
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	u "k8s.io/release/toolbox/util"
+)
+
+// testChangelog is a CHANGELOG-1.8.md with two releases.
+const testChangelog = `<!-- BEGIN MUNGE: GENERATED_TOC -->
+- [v1.8.1](#v181)
+<!-- END MUNGE: GENERATED_TOC -->
+
+<!-- NEW RELEASE NOTES ENTRY -->
+
+
+# v1.8.1
+
+## Changelog since v1.8.0
+
+` + "```" + `
+# v1.7.0
+` + "```" + `
+
+
+# v1.8.0-rc.1
+
+## Changelog since v1.8.0-beta.1
+
+* Fixes pods.
+`
+
+func TestParseChangelog(t *testing.T) {
+	c := parseChangelog(testChangelog)
+
+	wantHeader := "<!-- BEGIN MUNGE: GENERATED_TOC -->\n- [v1.8.1](#v181)\n<!-- END MUNGE: GENERATED_TOC -->\n\n<!-- NEW RELEASE NOTES ENTRY -->\n\n\n"
+	if c.Header != wantHeader {
+		t.Errorf("Header was incorrect, want: %q, got: %q", wantHeader, c.Header)
+	}
+	var versions []string
+	for _, r := range c.Releases {
+		versions = append(versions, r.Version)
+	}
+	if want := []string{"v1.8.1", "v1.8.0-rc.1"}; !reflect.DeepEqual(versions, want) {
+		t.Errorf("Releases were incorrect, want: %v, got: %v", want, versions)
+	}
+	if c.String() == testChangelog {
+		t.Errorf("Expected the TOC of the changelog to be regenerated")
+	}
+}
+
+func TestParseNotes(t *testing.T) {
+	r, err := parseNotes("**Release Note Preview - generated on Tue Oct  3**\n\n# v1.8.2\n\n## Changelog since v1.8.1\n")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if r.Version != "v1.8.2" || r.Content != "# v1.8.2\n\n## Changelog since v1.8.1\n" {
+		t.Errorf("Release was incorrect, got: %+v", r)
+	}
+
+	for _, notes := range []string{"## Changelog since v1.8.1\n", "# v1.8.2\n\n# v1.8.1\n"} {
+		if _, err := parseNotes(notes); err == nil {
+			t.Errorf("%q: Expected error", notes)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	tables := []struct {
+		version string
+		want    []string
+	}{
+		{"v1.8.2", []string{"v1.8.2", "v1.8.1", "v1.8.0-rc.1"}},
+		{"v1.8.0", []string{"v1.8.1", "v1.8.0", "v1.8.0-rc.1"}},
+		{"v1.8.0-beta.1", []string{"v1.8.1", "v1.8.0-rc.1", "v1.8.0-beta.1"}},
+		{"v1.8.1", []string{"v1.8.1", "v1.8.0-rc.1"}},
+	}
+
+	for _, table := range tables {
+		c := parseChangelog(testChangelog)
+		c.Update(Release{table.version, "# " + table.version + "\n\nNew notes.\n"})
+		var versions []string
+		for _, r := range c.Releases {
+			versions = append(versions, r.Version)
+		}
+		if !reflect.DeepEqual(versions, table.want) {
+			t.Errorf("%v: Releases were incorrect, want: %v, got: %v", table.version, table.want, versions)
+		}
+		if !strings.Contains(c.String(), "# "+table.version+"\n\nNew notes.\n\n\n") {
+			t.Errorf("%v: Changelog missing the new notes:\n%s", table.version, c.String())
+		}
+	}
+}
+
+func TestUpdateIdempotent(t *testing.T) {
+	r := Release{"v1.8.2", "# v1.8.2\n\n## Changelog since v1.8.1\n"}
+	for _, content := range []string{testChangelog, u.ChangelogStub} {
+		c := parseChangelog(content)
+		c.Update(r)
+		once := c.String()
+
+		c = parseChangelog(once)
+		c.Update(r)
+		if twice := c.String(); twice != once {
+			t.Errorf("Second update changed the changelog, want:\n%s\ngot:\n%s", once, twice)
+		}
+	}
+}
+
+func TestUpdateStub(t *testing.T) {
+	c := parseChangelog(u.ChangelogStub)
+	c.Update(Release{"v1.9.0-alpha.1", "# v1.9.0-alpha.1\n\n## Changelog since v1.8.0\n"})
+
+	want := "<!-- BEGIN MUNGE: GENERATED_TOC -->\n" +
+		"- [v1.9.0-alpha.1](#v190-alpha1)\n" +
+		"  - [Changelog since v1.8.0](#changelog-since-v180)\n" +
+		"<!-- END MUNGE: GENERATED_TOC -->\n\n<!-- NEW RELEASE NOTES ENTRY -->\n\n\n" +
+		"# v1.9.0-alpha.1\n\n## Changelog since v1.8.0\n\n\n"
+	if got := c.String(); got != want {
+		t.Errorf("Changelog was incorrect, want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestMarkdownTOC(t *testing.T) {
+	markdown := "# v1.8.1\n\n## Downloads for v1.8.1\n\n### Client Binaries\n\n" +
+		"```\n# not a heading\n```\n\n#not a heading either\n\n" +
+		"# v1.8.0\n\n## Downloads for v1.8.0\n\n### Client Binaries\n\n### Client Binaries\n"
+	want := "- [v1.8.1](#v181)\n" +
+		"  - [Downloads for v1.8.1](#downloads-for-v181)\n" +
+		"    - [Client Binaries](#client-binaries)\n" +
+		"- [v1.8.0](#v180)\n" +
+		"  - [Downloads for v1.8.0](#downloads-for-v180)\n" +
+		"    - [Client Binaries](#client-binaries-1)\n" +
+		"    - [Client Binaries](#client-binaries-2)\n"
+
+	if got := markdownTOC(markdown); got != want {
+		t.Errorf("TOC was incorrect, want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestTOCAnchor(t *testing.T) {
+	tables := []struct {
+		heading string
+		want    string
+	}{
+		{"v1.8.0-rc.1", "v180-rc1"},
+		{"Changelog since v1.8.0", "changelog-since-v180"},
+		{"Known Issues (Node/API)", "known-issues-nodeapi"},
+		{"What's New?", "whats-new"},
+		{"Use `kubectl apply --prune`!", "use-kubectl-apply---prune"},
+		{"\"Deprecated\" flags & options", "deprecated-flags--options"},
+		{"Issue #123: C++ bindings", "issue-123-c-bindings"},
+		{"snake_case Fields", "snake_case-fields"},
+		{"Überblick", "überblick"},
+	}
+
+	for _, table := range tables {
+		if got := tocAnchor(table.heading); got != table.want {
+			t.Errorf("%q: Anchor was incorrect, want: %v, got: %v", table.heading, table.want, got)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tables := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{"v1.8.1", "v1.8.1", 0},
+		{"v1.8.1", "v1.8.0", 1},
+		{"v1.10.0", "v1.9.0", 1},
+		{"v1.8.0", "v1.8.0-rc.1", 1},
+		{"v1.8.0-alpha.2", "v1.8.0-beta.1", -1},
+		{"v1.8.0-beta.10", "v1.8.0-beta.9", 1},
+		{"v1.8.0-beta", "v1.8.0-beta.0", -1},
+		{"v1.8.0-1", "v1.8.0-alpha", -1},
+		{"foo", "v1.8.0", -1},
+	}
+
+	for _, table := range tables {
+		if got := compareVersions(table.a, table.b); got != table.want {
+			t.Errorf("%v vs %v: Comparison was incorrect, want: %v, got: %v", table.a, table.b, table.want, got)
+		}
+		if got := compareVersions(table.b, table.a); got != -table.want {
+			t.Errorf("%v vs %v: Comparison was incorrect, want: %v, got: %v", table.b, table.a, -table.want, got)
+		}
+	}
+}
+
+func TestChangelogFileName(t *testing.T) {
+	tables := []struct {
+		version string
+		want    string
+		err     bool
+	}{
+		{"v1.8.1", "CHANGELOG-1.8.md", false},
+		{"v1.10.0-alpha.1", "CHANGELOG-1.10.md", false},
+		{"1.8.1", "", true},
+	}
+
+	for _, table := range tables {
+		got, err := changelogFileName(table.version)
+		if (err != nil) != table.err || got != table.want {
+			t.Errorf("%v: File name was incorrect, want: %v (error: %v), got: %v (%v)", table.version, table.want, table.err, got, err)
+		}
+	}
+}
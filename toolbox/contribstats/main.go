@@ -0,0 +1,286 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/github"
+	u "k8s.io/release/toolbox/util"
+)
+
+var (
+	// Flags
+	branch      = flag.String("branch", "", "Specify a branch other than the current one")
+	format      = flag.String("format", "markdown", "Output format: markdown or json")
+	githubToken = flag.String("github-token", "", "The file that contains Github token. Must be specified, or set the GITHUB_TOKEN environment variable.")
+	outFileName = flag.String("output", "", "Write the report to a file instead of stdout")
+	owner       = flag.String("owner", "kubernetes", "Github owner or organization")
+	repo        = flag.String("repo", "kubernetes", "Github repository")
+	top         = flag.Int("top-reviewers", 10, "Number of top reviewers to report")
+)
+
+// ContributorStats contains contributor statistics for a release range.
+type ContributorStats struct {
+	StartTag              string          `json:"startTag"`
+	ReleaseTag            string          `json:"releaseTag"`
+	Commits               int             `json:"commits"`
+	PullRequests          int             `json:"pullRequests"`
+	Authors               []string        `json:"authors"`
+	FirstTimeContributors []string        `json:"firstTimeContributors"`
+	TopReviewers          []ReviewerCount `json:"topReviewers"`
+}
+
+// ReviewerCount is the number of pull requests a user reviewed.
+type ReviewerCount struct {
+	Login   string `json:"login"`
+	Reviews int    `json:"reviews"`
+}
+
+func main() {
+	flag.Parse()
+	branchRange := flag.Arg(0)
+
+	if *format != "markdown" && *format != "json" {
+		log.Printf("unknown output format %q", *format)
+		os.Exit(1)
+	}
+
+	if *branch == "" {
+		// If branch isn't specified in flag, use current branch
+		var err error
+		*branch, err = u.GetCurrentBranch()
+		if err != nil {
+			log.Printf("failed to get current branch: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	if *githubToken == "" {
+		// If githubToken isn't specified in flag, use the GITHUB_TOKEN environment variable
+		*githubToken = os.Getenv("GITHUB_TOKEN")
+	} else {
+		token, err := u.ReadToken(*githubToken)
+		if err != nil {
+			log.Printf("failed to read Github token: %v", err)
+			os.Exit(1)
+		}
+		*githubToken = token
+	}
+	// Github token must be provided to ensure great rate limit experience
+	if *githubToken == "" {
+		log.Print("Github token not provided. Exiting now...")
+		os.Exit(1)
+	}
+	client := u.NewClient(*githubToken)
+
+	stats, err := gatherStats(client, *owner, *repo, *branch, branchRange, *top)
+	if err != nil {
+		log.Printf("failed to gather contributor statistics: %v", err)
+		os.Exit(1)
+	}
+
+	if err = writeReport(*outFileName, *format, stats); err != nil {
+		log.Printf("failed to write report: %v", err)
+		os.Exit(1)
+	}
+}
+
+// writeReport writes input stats in format to the file at path, or to stdout if path is empty.
+// The file is closed before returning, so the report is complete once the function succeeds.
+func writeReport(path, format string, stats *ContributorStats) error {
+	out := os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create output file %s: %v", path, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	var err error
+	if format == "json" {
+		err = writeJSON(out, stats)
+	} else {
+		err = writeMarkdown(out, stats)
+	}
+	if err != nil {
+		return err
+	}
+	if out != os.Stdout {
+		return out.Close()
+	}
+	return nil
+}
+
+// gatherStats collects contributor statistics for the commits on branch in branchRange.
+func gatherStats(g *u.GithubClient, owner, repo, branch, branchRange string, topN int) (*ContributorStats, error) {
+	startTag, releaseTag, _, err := g.DetermineRange(owner, repo, branch, branchRange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine branch range: %v", err)
+	}
+	log.Printf("Gathering commits for %s..%s on %s...", startTag, releaseTag, branch)
+
+	commits, err := g.ListReleaseCommits(owner, repo, branch, startTag, releaseTag)
+	if err != nil {
+		return nil, err
+	}
+	prs, err := u.ParsePRFromCommit(commits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse release commits: %v", err)
+	}
+
+	stats := &ContributorStats{
+		StartTag:     startTag,
+		ReleaseTag:   releaseTag,
+		Commits:      len(commits),
+		PullRequests: len(prs),
+		Authors:      commitAuthors(commits),
+	}
+
+	tags, err := g.ListAllTags(owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch repo tags: %v", err)
+	}
+	tStart, err := g.GetCommitDate(owner, repo, startTag, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Checking %d authors for earlier merged PRs...", len(stats.Authors))
+	stats.FirstTimeContributors = make([]string, 0)
+	for _, author := range stats.Authors {
+		var query []string
+		query = u.AddQuery(query, "repo", owner, "/", repo)
+		query = u.AddQuery(query, "type", "pr")
+		query = u.AddQuery(query, "is", "merged")
+		query = u.AddQuery(query, "author", author)
+		query = u.AddQuery(query, "merged", "<", tStart.Format("2006-01-02"))
+		n, err := g.CountIssues(strings.Join(query, " "))
+		if err != nil {
+			return nil, fmt.Errorf("failed to search PRs by %s: %v", author, err)
+		}
+		if n == 0 {
+			stats.FirstTimeContributors = append(stats.FirstTimeContributors, author)
+		}
+	}
+
+	log.Printf("Gathering reviews for %d PRs...", len(prs))
+	reviews := make(map[int][]*github.PullRequestReview)
+	for _, pr := range prs {
+		r, err := g.ListAllReviews(owner, repo, pr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list reviews of PR #%d: %v", pr, err)
+		}
+		reviews[pr] = r
+	}
+	stats.TopReviewers = topReviewers(reviews, topN)
+
+	return stats, nil
+}
+
+// commitAuthors returns the sorted, unique Github logins of the commit authors. Merge commits
+// are skipped because they are authored by whoever (or whatever bot) merged the PR.
+func commitAuthors(commits []*github.RepositoryCommit) []string {
+	seen := make(map[string]bool)
+	authors := make([]string, 0)
+	for _, c := range commits {
+		if len(c.Parents) > 1 || c.Author == nil || c.Author.Login == nil {
+			continue
+		}
+		if login := *c.Author.Login; !seen[login] {
+			seen[login] = true
+			authors = append(authors, login)
+		}
+	}
+	sort.Strings(authors)
+	return authors
+}
+
+// topReviewers counts the PRs each user reviewed and returns the n users with the most reviews.
+// Multiple reviews by the same user on one PR count once.
+func topReviewers(reviews map[int][]*github.PullRequestReview, n int) []ReviewerCount {
+	counts := make(map[string]int)
+	for _, rs := range reviews {
+		reviewed := make(map[string]bool)
+		for _, r := range rs {
+			if r.User == nil || r.User.Login == nil || reviewed[*r.User.Login] {
+				continue
+			}
+			reviewed[*r.User.Login] = true
+			counts[*r.User.Login]++
+		}
+	}
+
+	result := make([]ReviewerCount, 0, len(counts))
+	for login, c := range counts {
+		result = append(result, ReviewerCount{login, c})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Reviews != result[j].Reviews {
+			return result[i].Reviews > result[j].Reviews
+		}
+		return result[i].Login < result[j].Login
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+// writeJSON writes input stats to w as indented JSON.
+func writeJSON(w io.Writer, stats *ContributorStats) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(stats)
+}
+
+// writeMarkdown writes input stats to w as a markdown section for release announcements.
+func writeMarkdown(w io.Writer, stats *ContributorStats) error {
+	var b bytes.Buffer
+	b.WriteString(fmt.Sprintf("## Contributors to %s\n\n", stats.ReleaseTag))
+	b.WriteString(fmt.Sprintf("Since %s: %d commits and %d pull requests from %d authors.\n\n",
+		stats.StartTag, stats.Commits, stats.PullRequests, len(stats.Authors)))
+
+	if len(stats.FirstTimeContributors) > 0 {
+		b.WriteString(fmt.Sprintf("### First-time contributors (%d)\n\n", len(stats.FirstTimeContributors)))
+		for _, c := range stats.FirstTimeContributors {
+			b.WriteString(fmt.Sprintf("* @%s\n", c))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(stats.TopReviewers) > 0 {
+		b.WriteString("### Top reviewers\n\n")
+		b.WriteString("Reviewer | PRs reviewed\n")
+		b.WriteString("-------- | ------------\n")
+		for _, r := range stats.TopReviewers {
+			b.WriteString(fmt.Sprintf("@%s | %d\n", r.Login, r.Reviews))
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/github"
+)
+
+func TestCommitAuthors(t *testing.T) {
+	commit := func(login string, parents int) *github.RepositoryCommit {
+		c := &github.RepositoryCommit{Parents: make([]github.Commit, parents)}
+		if login != "" {
+			c.Author = &github.User{Login: github.String(login)}
+		}
+		return c
+	}
+	commits := []*github.RepositoryCommit{
+		commit("zoe", 1),
+		commit("k8s-merge-robot", 2),
+		commit("adam", 1),
+		commit("zoe", 1),
+		commit("", 1),
+	}
+
+	want := []string{"adam", "zoe"}
+	got := commitAuthors(commits)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Authors were incorrect, want: %v, got: %v", want, got)
+	}
+}
+
+func TestTopReviewers(t *testing.T) {
+	review := func(login string) *github.PullRequestReview {
+		return &github.PullRequestReview{User: &github.User{Login: github.String(login)}}
+	}
+	reviews := map[int][]*github.PullRequestReview{
+		1: {review("alice"), review("alice"), review("bob")},
+		2: {review("bob")},
+		3: {review("carol"), review("alice")},
+	}
+
+	tables := []struct {
+		n    int
+		want []ReviewerCount
+	}{
+		{2, []ReviewerCount{{"alice", 2}, {"bob", 2}}},
+		{5, []ReviewerCount{{"alice", 2}, {"bob", 2}, {"carol", 1}}},
+	}
+
+	for _, table := range tables {
+		got := topReviewers(reviews, table.n)
+		if len(got) != len(table.want) {
+			t.Errorf("%d: Number of reviewers was incorrect, want: %v, got: %v", table.n, table.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != table.want[i] {
+				t.Errorf("%d: Reviewer was incorrect, want: %v, got: %v", table.n, table.want[i], got[i])
+			}
+		}
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	stats := &ContributorStats{
+		StartTag:              "v1.8.0",
+		ReleaseTag:            "v1.8.1",
+		Commits:               12,
+		PullRequests:          5,
+		Authors:               []string{"adam", "zoe"},
+		FirstTimeContributors: []string{"zoe"},
+		TopReviewers:          []ReviewerCount{{"alice", 2}},
+	}
+
+	var md bytes.Buffer
+	if err := writeMarkdown(&md, stats); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, s := range []string{"12 commits and 5 pull requests from 2 authors", "* @zoe\n", "@alice | 2\n"} {
+		if !strings.Contains(md.String(), s) {
+			t.Errorf("Markdown report missing %q:\n%s", s, md.String())
+		}
+	}
+
+	var js bytes.Buffer
+	if err := writeJSON(&js, stats); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var decoded ContributorStats
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decoded.Commits != 12 || len(decoded.FirstTimeContributors) != 1 {
+		t.Errorf("JSON report was incorrect: %s", js.String())
+	}
+
+	dir, err := ioutil.TempDir("", "contribstats")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "report.json")
+	if err = writeReport(path, "json", stats); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if b, _ := ioutil.ReadFile(path); string(b) != js.String() {
+		t.Errorf("Report file was incorrect, want: %s, got: %s", js.String(), b)
+	}
+	if err = writeReport(filepath.Join(dir, "missing", "report.json"), "json", stats); err == nil {
+		t.Errorf("Expected error writing to missing directory")
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	u "k8s.io/release/toolbox/util"
+)
+
+var (
+	// Flags
+	branchPoint = flag.String("branch-point", "master", "Commit or branch to create the new release branch from")
+	changelog   = flag.Bool("changelog", false, "Add a CHANGELOG-X.Y.md stub on the parent branch")
+	nomock      = flag.Bool("nomock", false, "Push the branch and tags to the remote. Without it the push is a dry run")
+	remote      = flag.String("remote", "origin", "Git remote to push to")
+	repoDir     = flag.String("repo-dir", ".", "Local clone of the repository to create the branch in")
+	sign        = flag.Bool("sign", false, "GPG-sign the release tags instead of only annotating them")
+	yes         = flag.Bool("yes", false, "Skip the confirmation prompt before making changes")
+)
+
+// BranchPlan contains everything needed to create a new release branch.
+type BranchPlan struct {
+	Branch       string
+	ParentBranch string
+	BranchPoint  string
+	// BetaTag is tagged on the branch point of the new branch, e.g. v1.9.0-beta.0
+	BetaTag string
+	// AlphaTag is tagged on the branch point of the parent branch, e.g. v1.10.0-alpha.0
+	AlphaTag string
+	// ChangelogFile is the changelog stub to add on the parent branch, empty if none.
+	ChangelogFile string
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] release-X.Y\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	plan, err := newBranchPlan(*repoDir, flag.Arg(0), *branchPoint, *changelog)
+	if err != nil {
+		log.Printf("failed to plan branch creation: %v", err)
+		os.Exit(1)
+	}
+
+	printPlan(os.Stdout, plan, *remote, !*nomock)
+	if !*yes && !askYesOrNo(os.Stdin, os.Stdout, "Continue?") {
+		log.Print("Exiting...")
+		os.Exit(1)
+	}
+
+	if err = createBranch(*repoDir, *remote, plan, *sign, !*nomock); err != nil {
+		log.Printf("failed to create branch %s: %v", plan.Branch, err)
+		os.Exit(1)
+	}
+	if *nomock {
+		log.Printf("Pushed %s and tags to %s.", plan.Branch, *remote)
+	} else {
+		log.Print("Dry run push succeeded. Use --nomock to push for real.")
+	}
+}
+
+// createBranch executes input plan in the repository at dir and pushes it to remote. After a dry
+// run push or a failed push the local branch, tags and changelog commit are rolled back, so that
+// the same branch can be planned and created again.
+func createBranch(dir, remote string, plan *BranchPlan, sign, dryRun bool) error {
+	rollback, err := executePlan(dir, plan, sign)
+	if err != nil {
+		return err
+	}
+	log.Printf("Created branch %s and tags %s, %s locally.", plan.Branch, plan.BetaTag, plan.AlphaTag)
+
+	err = pushPlan(dir, remote, plan, dryRun)
+	if err != nil || dryRun {
+		log.Printf("Removing local branch %s and tags %s, %s...", plan.Branch, plan.BetaTag, plan.AlphaTag)
+		rollback()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to push: %v", err)
+	}
+	return nil
+}
+
+// newBranchPlan computes the branch point, tags and files for creating input branch from the
+// repository at dir. Input branch must be in the format of release-X.Y, and neither the branch
+// nor its tags may already exist.
+func newBranchPlan(dir, branch, branchPoint string, changelog bool) (*BranchPlan, error) {
+	betaTag, alphaTag, err := branchTags(branch)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = u.RevParse(dir, "refs/heads/"+branch); err == nil {
+		return nil, fmt.Errorf("branch %s already exists", branch)
+	}
+	for _, tag := range []string{betaTag, alphaTag} {
+		if _, err = u.RevParse(dir, "refs/tags/"+tag); err == nil {
+			return nil, fmt.Errorf("tag %s already exists", tag)
+		}
+	}
+
+	sha, err := u.RevParse(dir, branchPoint+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve branch point %s: %v", branchPoint, err)
+	}
+
+	plan := &BranchPlan{
+		Branch:       branch,
+		ParentBranch: "master",
+		BranchPoint:  sha,
+		BetaTag:      betaTag,
+		AlphaTag:     alphaTag,
+	}
+	if changelog {
+		file := fmt.Sprintf("CHANGELOG%s.md", strings.TrimPrefix(branch, "release"))
+		if _, err = u.Git(dir, "cat-file", "-e", plan.ParentBranch+":"+file); err == nil {
+			log.Printf("%s already exists on %s, skipping stub", file, plan.ParentBranch)
+		} else {
+			plan.ChangelogFile = file
+		}
+	}
+	return plan, nil
+}
+
+// branchTags computes the tags to seed the versions with when creating input branch off of
+// master, the same way release::set_release_version does. For example, "release-1.9" gets
+// "v1.9.0-beta.0" on the new branch and "v1.10.0-alpha.0" on master.
+func branchTags(branch string) (betaTag, alphaTag string, err error) {
+	re, _ := regexp.Compile("^release-([0-9]+)\\.([0-9]+)$")
+	ver := re.FindStringSubmatch(branch)
+	if ver == nil {
+		return "", "", fmt.Errorf("invalid branch format %q, want release-X.Y", branch)
+	}
+	minor, err := strconv.Atoi(ver[2])
+	if err != nil {
+		return "", "", err
+	}
+
+	betaTag = fmt.Sprintf("v%s.%d.0-beta.0", ver[1], minor)
+	alphaTag = fmt.Sprintf("v%s.%d.0-alpha.0", ver[1], minor+1)
+	return betaTag, alphaTag, nil
+}
+
+// printPlan prints out the steps executing input plan would take.
+func printPlan(w io.Writer, plan *BranchPlan, remote string, dryRun bool) {
+	fmt.Fprintf(w, "Creating branch %s:\n\n", plan.Branch)
+	fmt.Fprintf(w, "* Create branch %s at %s\n", plan.Branch, plan.BranchPoint)
+	fmt.Fprintf(w, "* Tag %s on %s (%q)\n", plan.BetaTag, plan.Branch, u.ReleaseTagMessage(plan.BetaTag))
+	fmt.Fprintf(w, "* Tag %s on %s (%q)\n", plan.AlphaTag, plan.ParentBranch, u.ReleaseTagMessage(plan.AlphaTag))
+	if plan.ChangelogFile != "" {
+		fmt.Fprintf(w, "* Commit %s stub on %s\n", plan.ChangelogFile, plan.ParentBranch)
+	}
+
+	var mode string
+	if dryRun {
+		mode = " (dry run)"
+	}
+	fmt.Fprintf(w, "* Push %s and tags to %s atomically%s\n\n", strings.Join(pushBranches(plan), ", "), remote, mode)
+}
+
+// askYesOrNo prompts the user with input question and reads the answer from r.
+func askYesOrNo(r io.Reader, w io.Writer, question string) bool {
+	fmt.Fprintf(w, "%s (y/n) ", question)
+	answer, _ := bufio.NewReader(r).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// executePlan creates the branch, tags and changelog stub of input plan in the repository at
+// dir. Nothing is pushed. On failure, the steps already taken are rolled back, so that the plan
+// can be executed again. On success, it returns the function rolling back all the steps.
+func executePlan(dir string, plan *BranchPlan, sign bool) (_ func(), err error) {
+	// undo holds the rollback of each step taken so far, in order
+	var undo []func() error
+	rollback := func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			if uerr := undo[i](); uerr != nil {
+				log.Printf("failed to roll back: %v", uerr)
+			}
+		}
+	}
+	defer func() {
+		if err != nil {
+			rollback()
+		}
+	}()
+	git := func(arg ...string) func() error {
+		return func() error {
+			_, err := u.Git(dir, arg...)
+			return err
+		}
+	}
+
+	if _, err = u.Git(dir, "branch", plan.Branch, plan.BranchPoint); err != nil {
+		return nil, err
+	}
+	undo = append(undo, git("branch", "-D", plan.Branch))
+
+	// Tag before committing the changelog on the parent branch, so the alpha tag stays on the
+	// branch point.
+	for _, tag := range []string{plan.BetaTag, plan.AlphaTag} {
+		if err = u.CreateTag(dir, tag, plan.BranchPoint, u.ReleaseTagMessage(tag), sign); err != nil {
+			return nil, err
+		}
+		undo = append(undo, git("tag", "-d", tag))
+		if err = u.VerifyTag(dir, tag, plan.BranchPoint); err != nil {
+			return nil, err
+		}
+	}
+
+	if plan.ChangelogFile == "" {
+		return rollback, nil
+	}
+	head, err := u.Git(dir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	parent, err := u.RevParse(dir, "refs/heads/"+plan.ParentBranch)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = u.Git(dir, "checkout", plan.ParentBranch); err != nil {
+		return nil, err
+	}
+	undo = append(undo, git("checkout", head))
+	changelogPath := filepath.Join(dir, plan.ChangelogFile)
+	if err = ioutil.WriteFile(changelogPath, []byte(u.ChangelogStub), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %v", changelogPath, err)
+	}
+	undo = append(undo, func() error { return os.Remove(changelogPath) })
+	if _, err = u.Git(dir, "add", plan.ChangelogFile); err != nil {
+		return nil, err
+	}
+	undo = append(undo, git("rm", "--cached", "--quiet", plan.ChangelogFile))
+	if _, err = u.Git(dir, "commit", "-m", fmt.Sprintf("Add %s for %s.", plan.ChangelogFile, plan.Branch)); err != nil {
+		return nil, err
+	}
+	// Drops the stub commit, leaving the stub staged for the steps above to remove
+	undo = append(undo, git("update-ref", "refs/heads/"+plan.ParentBranch, parent))
+	return rollback, nil
+}
+
+// pushPlan pushes the branches and tags of input plan to remote in one atomic push.
+func pushPlan(dir, remote string, plan *BranchPlan, dryRun bool) error {
+	return u.PushAtomic(dir, remote, dryRun, pushBranches(plan), []string{plan.BetaTag, plan.AlphaTag})
+}
+
+// pushBranches lists the branches input plan updates.
+func pushBranches(plan *BranchPlan) []string {
+	branches := []string{plan.Branch}
+	if plan.ChangelogFile != "" {
+		branches = append(branches, plan.ParentBranch)
+	}
+	return branches
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	u "k8s.io/release/toolbox/util"
+	"k8s.io/release/toolbox/util/testutil"
+)
+
+func TestBranchTags(t *testing.T) {
+	tables := []struct {
+		branch string
+		beta   string
+		alpha  string
+		valid  bool
+	}{
+		{"release-1.9", "v1.9.0-beta.0", "v1.10.0-alpha.0", true},
+		{"release-2.0", "v2.0.0-beta.0", "v2.1.0-alpha.0", true},
+		{"release-1.9.1", "", "", false},
+		{"master", "", "", false},
+	}
+
+	for _, table := range tables {
+		beta, alpha, err := branchTags(table.branch)
+		if (err == nil) != table.valid {
+			t.Errorf("%v: Validity check failed, want: %v, got error: %v", table.branch, table.valid, err)
+		}
+		if beta != table.beta || alpha != table.alpha {
+			t.Errorf("%v: Tags were incorrect, want: %s %s, got: %s %s", table.branch, table.beta, table.alpha, beta, alpha)
+		}
+	}
+}
+
+func TestAskYesOrNo(t *testing.T) {
+	tables := []struct {
+		input  string
+		answer bool
+	}{
+		{"y\n", true},
+		{"YES\n", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+	}
+
+	for _, table := range tables {
+		var out bytes.Buffer
+		if a := askYesOrNo(strings.NewReader(table.input), &out, "Continue?"); a != table.answer {
+			t.Errorf("%q: Answer was incorrect, want: %v, got: %v", table.input, table.answer, a)
+		}
+	}
+}
+
+func TestCreateBranch(t *testing.T) {
+	dir, cleanup := testutil.SetupGitRepos(t)
+	defer cleanup()
+
+	plan, err := newBranchPlan(dir, "release-1.9", "master", true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if plan.ChangelogFile != "CHANGELOG-1.9.md" {
+		t.Errorf("Changelog file was incorrect, want: CHANGELOG-1.9.md, got: %s", plan.ChangelogFile)
+	}
+
+	if err = createBranch(dir, "origin", plan, false, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tags, err := u.RemoteTags(dir, "origin")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, tag := range []string{"v1.9.0-beta.0", "v1.10.0-alpha.0"} {
+		if tags[tag] != plan.BranchPoint {
+			t.Errorf("%v: Remote tag was incorrect, want: %s, got: %s", tag, plan.BranchPoint, tags[tag])
+		}
+	}
+	if out, _ := u.Git(dir, "ls-remote", "origin", "refs/heads/release-1.9"); !strings.HasPrefix(out, plan.BranchPoint) {
+		t.Errorf("Remote branch was incorrect, want: %s, got: %s", plan.BranchPoint, out)
+	}
+	if _, err = u.Git(dir, "fetch", "origin"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if out, _ := u.Git(dir, "show", "origin/master:CHANGELOG-1.9.md"); out != strings.TrimSpace(u.ChangelogStub) {
+		t.Errorf("Changelog stub was incorrect, got: %q", out)
+	}
+
+	// Planning the same branch again must fail
+	if _, err = newBranchPlan(dir, "release-1.9", "master", false); err == nil {
+		t.Errorf("Expected error planning existing branch")
+	}
+}
+
+func TestCreateBranchExistingChangelog(t *testing.T) {
+	dir, cleanup := testutil.SetupGitRepos(t)
+	defer cleanup()
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "CHANGELOG-1.9.md"), []byte("# v1.9.0\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, c := range [][]string{{"add", "CHANGELOG-1.9.md"}, {"commit", "-m", "Add changelog"}} {
+		if _, err := u.Git(dir, c...); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	plan, err := newBranchPlan(dir, "release-1.9", "master", true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if plan.ChangelogFile != "" {
+		t.Errorf("Changelog file was incorrect, want none, got: %s", plan.ChangelogFile)
+	}
+	if branches := pushBranches(plan); !reflect.DeepEqual(branches, []string{"release-1.9"}) {
+		t.Errorf("Pushed branches were incorrect, want: [release-1.9], got: %v", branches)
+	}
+}
+
+func TestExecutePlanRollback(t *testing.T) {
+	dir, cleanup := testutil.SetupGitRepos(t)
+	defer cleanup()
+
+	plan, err := newBranchPlan(dir, "release-1.9", "master", true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The alpha tag appearing after planning makes the execution fail halfway
+	if _, err = u.Git(dir, "tag", plan.AlphaTag); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err = executePlan(dir, plan, false); err == nil {
+		t.Fatalf("Expected error creating existing tag")
+	}
+
+	if _, err = u.RevParse(dir, "refs/heads/"+plan.Branch); err == nil {
+		t.Errorf("Expected branch %s to be rolled back", plan.Branch)
+	}
+	if _, err = u.RevParse(dir, "refs/tags/"+plan.BetaTag); err == nil {
+		t.Errorf("Expected tag %s to be rolled back", plan.BetaTag)
+	}
+
+	// Once the collision is gone the same plan succeeds
+	if _, err = u.Git(dir, "tag", "-d", plan.AlphaTag); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err = executePlan(dir, plan, false); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestCreateBranchAfterDryRun(t *testing.T) {
+	dir, cleanup := testutil.SetupGitRepos(t)
+	defer cleanup()
+
+	master, err := u.RevParse(dir, "refs/heads/master")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	plan, err := newBranchPlan(dir, "release-1.9", "master", true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err = createBranch(dir, "origin", plan, false, true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The dry run leaves nothing behind locally nor on the remote
+	if _, err = u.RevParse(dir, "refs/heads/"+plan.Branch); err == nil {
+		t.Errorf("Expected branch %s to be removed", plan.Branch)
+	}
+	for _, tag := range []string{plan.BetaTag, plan.AlphaTag} {
+		if _, err = u.RevParse(dir, "refs/tags/"+tag); err == nil {
+			t.Errorf("Expected tag %s to be removed", tag)
+		}
+	}
+	if sha, _ := u.RevParse(dir, "refs/heads/master"); sha != master {
+		t.Errorf("Master was incorrect, want: %s, got: %s", master, sha)
+	}
+	if out, _ := u.Git(dir, "status", "--porcelain"); out != "" {
+		t.Errorf("Expected clean work tree, got: %s", out)
+	}
+	if tags, _ := u.RemoteTags(dir, "origin"); len(tags) != 0 {
+		t.Errorf("Expected no remote tags, got: %v", tags)
+	}
+
+	// Then the real run
+	plan, err = newBranchPlan(dir, "release-1.9", "master", true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err = createBranch(dir, "origin", plan, false, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if out, _ := u.Git(dir, "ls-remote", "origin", "refs/heads/release-1.9"); !strings.HasPrefix(out, plan.BranchPoint) {
+		t.Errorf("Remote branch was incorrect, want: %s, got: %s", plan.BranchPoint, out)
+	}
+	if out, _ := u.Git(dir, "ls-remote", "origin", "refs/heads/master"); strings.HasPrefix(out, master) {
+		t.Errorf("Expected changelog stub commit on remote master, got: %s", out)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	openAPISpecPath = "api/openapi-spec/swagger.json"
+)
+
+// openAPISpec is the part of the OpenAPI (swagger 2.0) spec we compare between releases.
+type openAPISpec struct {
+	Definitions map[string]struct {
+		Description string `json:"description"`
+		Properties  map[string]struct {
+			Description string `json:"description"`
+		} `json:"properties"`
+		GroupVersionKind []struct {
+			Group   string `json:"group"`
+			Version string `json:"version"`
+			Kind    string `json:"kind"`
+		} `json:"x-kubernetes-group-version-kind"`
+	} `json:"definitions"`
+}
+
+// apiSurface is the flattened API surface of a release.
+type apiSurface struct {
+	// groupVersions contains "group/version" entries, with "core" as the legacy group name
+	groupVersions map[string]bool
+	// kinds contains "group/version.Kind" entries
+	kinds map[string]bool
+	// fields contains "Definition.field" entries
+	fields map[string]bool
+	// deprecated contains the "Definition" and "Definition.field" entries documented as
+	// deprecated
+	deprecated map[string]bool
+}
+
+// APIChanges contains the API changes between two releases.
+type APIChanges struct {
+	AddedGroupVersions   []string `json:"addedGroupVersions"`
+	RemovedGroupVersions []string `json:"removedGroupVersions"`
+	AddedKinds           []string `json:"addedKinds"`
+	RemovedKinds         []string `json:"removedKinds"`
+	AddedFields          []string `json:"addedFields"`
+	RemovedFields        []string `json:"removedFields"`
+	Deprecations         []string `json:"deprecations"`
+}
+
+// parseAPISurface parses input OpenAPI spec into its API surface.
+func parseAPISurface(content []byte) (*apiSurface, error) {
+	var spec openAPISpec
+	if err := json.Unmarshal(content, &spec); err != nil {
+		return nil, err
+	}
+
+	s := &apiSurface{
+		groupVersions: make(map[string]bool),
+		kinds:         make(map[string]bool),
+		fields:        make(map[string]bool),
+		deprecated:    make(map[string]bool),
+	}
+	for name, def := range spec.Definitions {
+		name = shortDefinitionName(name)
+		for _, gvk := range def.GroupVersionKind {
+			group := gvk.Group
+			if group == "" {
+				group = "core"
+			}
+			s.groupVersions[group+"/"+gvk.Version] = true
+			s.kinds[group+"/"+gvk.Version+"."+gvk.Kind] = true
+		}
+		if isDeprecated(def.Description) {
+			s.deprecated[name] = true
+		}
+		for field, prop := range def.Properties {
+			s.fields[name+"."+field] = true
+			if isDeprecated(prop.Description) {
+				s.deprecated[name+"."+field] = true
+			}
+		}
+	}
+	return s, nil
+}
+
+// shortDefinitionName strips the common package prefix of Kubernetes OpenAPI definition names,
+// e.g. "io.k8s.api.apps.v1beta2.Deployment" becomes "apps.v1beta2.Deployment".
+func shortDefinitionName(name string) string {
+	for _, prefix := range []string{"io.k8s.api.", "io.k8s.kubernetes.pkg.api.", "io.k8s.kubernetes.pkg.apis.", "io.k8s."} {
+		if strings.HasPrefix(name, prefix) {
+			return strings.TrimPrefix(name, prefix)
+		}
+	}
+	return name
+}
+
+// isDeprecated checks if input OpenAPI description documents a deprecation.
+func isDeprecated(description string) bool {
+	return strings.Contains(strings.ToLower(description), "deprecated")
+}
+
+// diffAPISurface compares the API surfaces of two releases. Fields are only reported as added
+// or removed for definitions present in both releases, since new and removed kinds already
+// cover the rest.
+func diffAPISurface(oldAPI, newAPI *apiSurface) *APIChanges {
+	c := &APIChanges{}
+	c.AddedGroupVersions, c.RemovedGroupVersions = diffSets(oldAPI.groupVersions, newAPI.groupVersions)
+	c.AddedKinds, c.RemovedKinds = diffSets(oldAPI.kinds, newAPI.kinds)
+
+	oldDefs := definitions(oldAPI.fields)
+	newDefs := definitions(newAPI.fields)
+	added, removed := diffSets(oldAPI.fields, newAPI.fields)
+	for _, f := range added {
+		if oldDefs[definitionOf(f)] {
+			c.AddedFields = append(c.AddedFields, f)
+		}
+	}
+	for _, f := range removed {
+		if newDefs[definitionOf(f)] {
+			c.RemovedFields = append(c.RemovedFields, f)
+		}
+	}
+
+	c.Deprecations, _ = diffSets(oldAPI.deprecated, newAPI.deprecated)
+	return c
+}
+
+// diffSets returns the sorted entries only in b (added) and only in a (removed).
+func diffSets(a, b map[string]bool) (added, removed []string) {
+	for k := range b {
+		if !a[k] {
+			added = append(added, k)
+		}
+	}
+	for k := range a {
+		if !b[k] {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// definitions returns the set of definitions owning input "Definition.field" entries.
+func definitions(fields map[string]bool) map[string]bool {
+	defs := make(map[string]bool)
+	for f := range fields {
+		defs[definitionOf(f)] = true
+	}
+	return defs
+}
+
+// definitionOf returns the definition part of a "Definition.field" entry.
+func definitionOf(field string) string {
+	return field[:strings.LastIndex(field, ".")]
+}
+
+// getAPIChanges computes the OpenAPI spec changes between startTag and releaseTag.
+func getAPIChanges(fetch fileFetcher, startTag, releaseTag string) (*APIChanges, error) {
+	surfaces := make([]*apiSurface, 0, 2)
+	for _, ref := range []string{startTag, releaseTag} {
+		content, err := fetch(openAPISpecPath, ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s at %s: %v", openAPISpecPath, ref, err)
+		}
+		s, err := parseAPISurface(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s at %s: %v", openAPISpecPath, ref, err)
+		}
+		surfaces = append(surfaces, s)
+	}
+	return diffAPISurface(surfaces[0], surfaces[1]), nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAPIChangesSection(t *testing.T) {
+	oldSpec := `{
+  "swagger": "2.0",
+  "definitions": {
+    "io.k8s.api.apps.v1beta1.Deployment": {
+      "description": "Deployment enables declarative updates for Pods and ReplicaSets.",
+      "properties": {
+        "spec": {"description": "Specification of the desired behavior of the Deployment."},
+        "rollbackTo": {"description": "The config this deployment is rolling back to."}
+      },
+      "x-kubernetes-group-version-kind": [{"group": "apps", "kind": "Deployment", "version": "v1beta1"}]
+    },
+    "io.k8s.api.core.v1.PodSpec": {
+      "description": "PodSpec is a description of a pod.",
+      "properties": {
+        "serviceAccount": {"description": "ServiceAccountName is an alias for ServiceAccountName."}
+      }
+    },
+    "io.k8s.api.core.v1.Pod": {
+      "properties": {"spec": {}},
+      "x-kubernetes-group-version-kind": [{"group": "", "kind": "Pod", "version": "v1"}]
+    }
+  }
+}`
+	newSpec := `{
+  "swagger": "2.0",
+  "definitions": {
+    "io.k8s.api.apps.v1beta1.Deployment": {
+      "description": "DEPRECATED - This group version of Deployment is deprecated by apps/v1beta2/Deployment.",
+      "properties": {
+        "spec": {"description": "Specification of the desired behavior of the Deployment."}
+      },
+      "x-kubernetes-group-version-kind": [{"group": "apps", "kind": "Deployment", "version": "v1beta1"}]
+    },
+    "io.k8s.api.apps.v1beta2.Deployment": {
+      "properties": {"spec": {}},
+      "x-kubernetes-group-version-kind": [{"group": "apps", "kind": "Deployment", "version": "v1beta2"}]
+    },
+    "io.k8s.api.core.v1.PodSpec": {
+      "description": "PodSpec is a description of a pod.",
+      "properties": {
+        "serviceAccount": {"description": "DeprecatedServiceAccount is a depreciated alias for ServiceAccountName. Deprecated: Use serviceAccountName instead."},
+        "priority": {"description": "The priority value."}
+      }
+    },
+    "io.k8s.api.core.v1.Pod": {
+      "properties": {"spec": {}},
+      "x-kubernetes-group-version-kind": [{"group": "", "kind": "Pod", "version": "v1"}]
+    }
+  }
+}`
+	fetch := func(path, ref string) ([]byte, error) {
+		if path != openAPISpecPath {
+			return nil, fmt.Errorf("unexpected path %s", path)
+		}
+		switch ref {
+		case "v1.7.0":
+			return []byte(oldSpec), nil
+		case "v1.8.0":
+			return []byte(newSpec), nil
+		}
+		return nil, fmt.Errorf("not found")
+	}
+
+	changes, err := getAPIChanges(fetch, "v1.7.0", "v1.8.0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var b bytes.Buffer
+	renderBlock(&b, "apiChanges", changes)
+	want := "## API Changes\n\n" +
+		"### New API Group Versions\n\n* `apps/v1beta2`\n\n" +
+		"### New Kinds\n\n* `apps/v1beta2.Deployment`\n\n" +
+		"### New Fields\n\n* `core.v1.PodSpec.priority`\n\n" +
+		"### Removed Fields\n\n* `apps.v1beta1.Deployment.rollbackTo`\n\n" +
+		"### Deprecations\n\n* `apps.v1beta1.Deployment`\n* `core.v1.PodSpec.serviceAccount`\n\n"
+	if b.String() != want {
+		t.Errorf("API changes section was incorrect, want:\n%s\ngot:\n%s", want, b.String())
+	}
+
+	changes, err = getAPIChanges(fetch, "v1.8.0", "v1.8.0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	b.Reset()
+	renderBlock(&b, "apiChanges", changes)
+	if !strings.Contains(b.String(), "No API changes") {
+		t.Errorf("Expected no API changes, got:\n%s", b.String())
+	}
+
+	if _, err := getAPIChanges(fetch, "v1.6.0", "v1.8.0"); err == nil {
+		t.Errorf("Expected error for missing spec")
+	}
+}
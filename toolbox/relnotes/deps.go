@@ -0,0 +1,231 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// depManifests lists the dependency manifests we know how to parse, in order of preference.
+var depManifests = []struct {
+	path  string
+	parse func([]byte) (map[string]string, error)
+}{
+	{"go.mod", parseGoMod},
+	{"Gopkg.lock", parseGopkgLock},
+	{"Godeps/Godeps.json", parseGodeps},
+}
+
+// DepChange is a dependency added, removed or bumped between two releases.
+type DepChange struct {
+	Name       string `json:"name"`
+	OldVersion string `json:"oldVersion,omitempty"`
+	NewVersion string `json:"newVersion,omitempty"`
+}
+
+// DepDiff contains the dependency changes between two releases.
+type DepDiff struct {
+	Added   []DepChange `json:"added"`
+	Removed []DepChange `json:"removed"`
+	Changed []DepChange `json:"changed"`
+	// ManifestChange is set when the releases have different dependency manifests, e.g.
+	// "Godeps/Godeps.json -> go.mod", in which case the versions are compared across manifests.
+	ManifestChange string `json:"manifestChange,omitempty"`
+}
+
+// getDeps fetches the first dependency manifest found in the repository at ref, and returns the
+// dependency-indexed map of versions along with the path of the manifest. Manifests which don't
+// exist at ref are skipped, any other error fetching them is returned.
+func getDeps(fetch fileFetcher, ref string) (map[string]string, string, error) {
+	for _, m := range depManifests {
+		content, err := fetch(m.path, ref)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get %s at %s: %v", m.path, ref, err)
+		}
+		log.Printf("Found dependency manifest %s at %s", m.path, ref)
+		deps, err := m.parse(content)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse %s at %s: %v", m.path, ref, err)
+		}
+		return deps, m.path, nil
+	}
+	return nil, "", fmt.Errorf("no dependency manifest found at %s", ref)
+}
+
+// diffDeps compares the dependencies of two releases.
+func diffDeps(oldDeps, newDeps map[string]string) *DepDiff {
+	diff := &DepDiff{}
+	for name, v := range newDeps {
+		old, ok := oldDeps[name]
+		if !ok {
+			diff.Added = append(diff.Added, DepChange{name, "", v})
+		} else if old != v {
+			diff.Changed = append(diff.Changed, DepChange{name, old, v})
+		}
+	}
+	for name, v := range oldDeps {
+		if _, ok := newDeps[name]; !ok {
+			diff.Removed = append(diff.Removed, DepChange{name, v, ""})
+		}
+	}
+
+	for _, changes := range [][]DepChange{diff.Added, diff.Removed, diff.Changed} {
+		sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	}
+	return diff
+}
+
+// getDepDiff computes the dependency changes between startTag and releaseTag.
+func getDepDiff(fetch fileFetcher, startTag, releaseTag string) (*DepDiff, error) {
+	oldDeps, oldManifest, err := getDeps(fetch, startTag)
+	if err != nil {
+		return nil, err
+	}
+	newDeps, newManifest, err := getDeps(fetch, releaseTag)
+	if err != nil {
+		return nil, err
+	}
+	diff := diffDeps(oldDeps, newDeps)
+	if oldManifest != newManifest {
+		log.Printf("Dependency manifest changed from %s at %s to %s at %s", oldManifest, startTag, newManifest, releaseTag)
+		diff.ManifestChange = oldManifest + " -> " + newManifest
+	}
+	return diff, nil
+}
+
+// shortVersion shortens full commit SHAs to 12 characters, and leaves other versions as is.
+func shortVersion(v string) string {
+	re, _ := regexp.Compile("^[0-9a-f]{40}$")
+	if re.MatchString(v) {
+		return v[:12]
+	}
+	return v
+}
+
+// parseGoMod parses the require directives of a go.mod file.
+func parseGoMod(content []byte) (map[string]string, error) {
+	deps := make(map[string]string)
+	inRequire := false
+	for _, line := range strings.Split(string(content), "\n") {
+		if i := strings.Index(line, "//"); i != -1 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case inRequire && fields[0] == ")":
+			inRequire = false
+		case inRequire && len(fields) == 2:
+			deps[fields[0]] = fields[1]
+		case fields[0] == "require" && len(fields) == 2 && fields[1] == "(":
+			inRequire = true
+		case fields[0] == "require" && len(fields) == 3:
+			deps[fields[1]] = fields[2]
+		}
+	}
+	return deps, nil
+}
+
+// parseGopkgLock parses the [[projects]] entries of a dep Gopkg.lock file. The version of a
+// project is its tagged version if any, otherwise its revision.
+func parseGopkgLock(content []byte) (map[string]string, error) {
+	deps := make(map[string]string)
+	var name, version, revision string
+	flush := func() {
+		if name == "" {
+			return
+		}
+		if version != "" {
+			deps[name] = version
+		} else {
+			deps[name] = revision
+		}
+		name, version, revision = "", "", ""
+	}
+
+	re, _ := regexp.Compile("^\\s*(name|version|revision)\\s*=\\s*\"(.*)\"\\s*$")
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == "[[projects]]" || strings.HasPrefix(line, "[solve-meta]") {
+			flush()
+			continue
+		}
+		if kv := re.FindStringSubmatch(line); kv != nil {
+			switch kv[1] {
+			case "name":
+				name = kv[2]
+			case "version":
+				version = kv[2]
+			case "revision":
+				revision = kv[2]
+			}
+		}
+	}
+	flush()
+	return deps, nil
+}
+
+// parseGodeps parses a godep Godeps.json file. Godeps lists every vendored package, so packages
+// are folded into their repositories. The version of a repository is its "git describe" comment
+// if any, otherwise its revision.
+func parseGodeps(content []byte) (map[string]string, error) {
+	var godeps struct {
+		Deps []struct {
+			ImportPath string
+			Comment    string
+			Rev        string
+		}
+	}
+	if err := json.Unmarshal(content, &godeps); err != nil {
+		return nil, err
+	}
+
+	deps := make(map[string]string)
+	for _, d := range godeps.Deps {
+		v := d.Rev
+		if d.Comment != "" {
+			v = d.Comment
+		}
+		deps[repoRoot(d.ImportPath)] = v
+	}
+	return deps, nil
+}
+
+// repoRoot guesses the repository root of a Go import path. For example:
+//
+//     "github.com/google/go-github/github" - "github.com/google/go-github"
+//     "golang.org/x/net/context"           - "golang.org/x/net"
+//     "k8s.io/api/core/v1"                 - "k8s.io/api"
+func repoRoot(importPath string) string {
+	parts := strings.Split(importPath, "/")
+	n := 2
+	switch parts[0] {
+	case "github.com", "bitbucket.org", "gitlab.com", "golang.org":
+		n = 3
+	}
+	if len(parts) > n {
+		parts = parts[:n]
+	}
+	return strings.Join(parts, "/")
+}
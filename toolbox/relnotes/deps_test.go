@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestParseDepManifests(t *testing.T) {
+	goMod := `module k8s.io/kubernetes
+
+go 1.12
+
+require github.com/blang/semver v3.5.0+incompatible
+
+require (
+	github.com/google/go-github v17.0.0+incompatible // indirect
+	golang.org/x/oauth2 v0.0.0-20190220154721-9b3c75971fc9
+)
+
+replace golang.org/x/oauth2 => golang.org/x/oauth2 v0.0.0-20170412232759-a6bd8cefa181
+`
+	gopkgLock := `[[projects]]
+  name = "github.com/blang/semver"
+  packages = ["."]
+  revision = "2ee87856327ba09384cabd113bc6b5d174e9ec0f"
+  version = "v3.5.1"
+
+[[projects]]
+  branch = "master"
+  name = "golang.org/x/oauth2"
+  packages = [".","internal"]
+  revision = "bb50c06baba3d0c76f9d125c0719093e315b5b44"
+
+[solve-meta]
+  analyzer-name = "dep"
+  inputs-digest = "c08299dd9abf1a3db1d7f80e48f1b0bc4dd46a8ff832ec85faf881930f144728"
+`
+	godeps := `{
+	"ImportPath": "k8s.io/kubernetes",
+	"Deps": [
+		{"ImportPath": "github.com/google/go-github/github", "Rev": "7de811820d2b3b6ef66f466bdae21e494a52b633"},
+		{"ImportPath": "golang.org/x/net/context", "Comment": "v0.1.0", "Rev": "a04bdaca5b32abe1c069418fb7088ae607de5bd0"},
+		{"ImportPath": "golang.org/x/net/http2", "Comment": "v0.1.0", "Rev": "a04bdaca5b32abe1c069418fb7088ae607de5bd0"}
+	]
+}`
+
+	tables := []struct {
+		name    string
+		parse   func([]byte) (map[string]string, error)
+		content string
+		deps    map[string]string
+	}{
+		{"go.mod", parseGoMod, goMod, map[string]string{
+			"github.com/blang/semver":     "v3.5.0+incompatible",
+			"github.com/google/go-github": "v17.0.0+incompatible",
+			"golang.org/x/oauth2":         "v0.0.0-20190220154721-9b3c75971fc9",
+		}},
+		{"Gopkg.lock", parseGopkgLock, gopkgLock, map[string]string{
+			"github.com/blang/semver": "v3.5.1",
+			"golang.org/x/oauth2":     "bb50c06baba3d0c76f9d125c0719093e315b5b44",
+		}},
+		{"Godeps.json", parseGodeps, godeps, map[string]string{
+			"github.com/google/go-github": "7de811820d2b3b6ef66f466bdae21e494a52b633",
+			"golang.org/x/net":            "v0.1.0",
+		}},
+	}
+
+	for _, table := range tables {
+		deps, err := table.parse([]byte(table.content))
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", table.name, err)
+			continue
+		}
+		if len(deps) != len(table.deps) {
+			t.Errorf("%v: Dependencies were incorrect, want: %v, got: %v", table.name, table.deps, deps)
+		}
+		for k, v := range table.deps {
+			if deps[k] != v {
+				t.Errorf("%v %v: Version was incorrect, want: %v, got: %v", table.name, k, v, deps[k])
+			}
+		}
+	}
+}
+
+func TestDepsSection(t *testing.T) {
+	files := map[string]string{
+		"v1.8.0:Godeps/Godeps.json": `{"Deps": [
+			{"ImportPath": "github.com/old/removed", "Rev": "1111111111111111111111111111111111111111"},
+			{"ImportPath": "github.com/same/dep", "Comment": "v1.0.0", "Rev": "2222222222222222222222222222222222222222"},
+			{"ImportPath": "github.com/bumped/dep", "Comment": "v1.0.0", "Rev": "3333333333333333333333333333333333333333"}
+		]}`,
+		"v1.9.0:Gopkg.lock": `[[projects]]
+  name = "github.com/same/dep"
+  revision = "2222222222222222222222222222222222222222"
+  version = "v1.0.0"
+
+[[projects]]
+  name = "github.com/bumped/dep"
+  revision = "4444444444444444444444444444444444444444"
+
+[[projects]]
+  name = "github.com/new/added"
+  revision = "5555555555555555555555555555555555555555"
+  version = "v0.2.0"
+`,
+	}
+	fetch := func(path, ref string) ([]byte, error) {
+		if c, ok := files[ref+":"+path]; ok {
+			return []byte(c), nil
+		}
+		return nil, os.ErrNotExist
+	}
+
+	diff, err := getDepDiff(fetch, "v1.8.0", "v1.9.0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var b bytes.Buffer
+	if err := renderBlock(&b, "dependencies", diff); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := `## Dependencies
+
+**The dependency manifest changed (Godeps/Godeps.json -> Gopkg.lock), versions are compared across manifests**
+
+### Added
+
+* github.com/new/added: v0.2.0
+
+### Changed
+
+* github.com/bumped/dep: v1.0.0 -> 444444444444
+
+### Removed
+
+* github.com/old/removed: 111111111111
+
+`
+	if b.String() != want {
+		t.Errorf("Dependencies section was incorrect, want:\n%s\ngot:\n%s", want, b.String())
+	}
+
+	if _, err := getDepDiff(fetch, "v1.7.0", "v1.9.0"); err == nil {
+		t.Errorf("Expected error for missing manifest")
+	}
+
+	// Failures other than missing manifests aren't skipped
+	failing := func(path, ref string) ([]byte, error) {
+		if path == "go.mod" {
+			return nil, fmt.Errorf("API rate limit exceeded")
+		}
+		return fetch(path, ref)
+	}
+	if _, err := getDepDiff(failing, "v1.8.0", "v1.9.0"); err == nil {
+		t.Errorf("Expected error for failed fetch")
+	}
+}
+
+func TestRepoRoot(t *testing.T) {
+	tables := []struct {
+		importPath string
+		root       string
+	}{
+		{"github.com/google/go-github/github", "github.com/google/go-github"},
+		{"golang.org/x/net/context", "golang.org/x/net"},
+		{"k8s.io/api/core/v1", "k8s.io/api"},
+		{"gopkg.in/yaml.v2", "gopkg.in/yaml.v2"},
+	}
+
+	for _, table := range tables {
+		if r := repoRoot(table.importPath); r != table.root {
+			t.Errorf("%v: Repository root was incorrect, want: %v, got: %v", table.importPath, table.root, r)
+		}
+	}
+}
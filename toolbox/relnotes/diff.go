@@ -0,0 +1,293 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// notesDiff is the difference between two release note documents, in the order of the new
+// document (or of the old one for removed notes).
+type notesDiff struct {
+	Added   []diffEntry
+	Removed []diffEntry
+	Moved   []diffEntry
+	Changed []diffEntry
+}
+
+// diffEntry is a release note which differs between two documents.
+type diffEntry struct {
+	Entry NoteEntry
+	// OldSection and Section are the sections the note was and is in, e.g. "Bug Fixes" or
+	// "Bug Fixes / SIG Node" for SIG subsections
+	OldSection string
+	Section    string
+	OldText    string
+}
+
+// noteLocation is a release note and the section it's in.
+type noteLocation struct {
+	entry   NoteEntry
+	section string
+}
+
+// runDiff runs the "relnotes diff [--markdown] old.json new.json" subcommand, and returns its
+// exit code.
+func runDiff(args []string, w io.Writer, errw io.Writer) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(errw)
+	markdown := fs.Bool("markdown", false, "Output a markdown \"Changes since draft\" block for reviewers instead of text")
+	fs.Usage = func() {
+		fmt.Fprint(errw, "Usage: relnotes diff [--markdown] old.json new.json\n\n")
+		fmt.Fprint(errw, "Compares two --format=json release note documents.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	old, err := loadNotes(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(errw, err)
+		return 1
+	}
+	updated, err := loadNotes(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintln(errw, err)
+		return 1
+	}
+
+	d := diffNotes(old, updated)
+	if *markdown {
+		err = writeDiffMarkdown(w, d)
+	} else {
+		err = writeDiffText(w, d, fs.Arg(0), fs.Arg(1))
+	}
+	if err != nil {
+		fmt.Fprintf(errw, "failed to write diff: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// loadNotes reads a release note document generated with --format=json.
+func loadNotes(filename string) (*ReleaseNotes, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release notes %s: %v", filename, err)
+	}
+	notes := &ReleaseNotes{}
+	if err = json.Unmarshal(content, notes); err != nil {
+		return nil, fmt.Errorf("failed to parse release notes %s: %v", filename, err)
+	}
+	return notes, nil
+}
+
+// noteLocations flattens input sections into the list of notes and their section, and indexes
+// them by PR.
+func noteLocations(sections []NoteSection) ([]string, map[string]noteLocation) {
+	keys := make([]string, 0)
+	locations := make(map[string]noteLocation)
+	var add func(sections []NoteSection, parent string)
+	add = func(sections []NoteSection, parent string) {
+		for _, s := range sections {
+			title := s.Title
+			if parent != "" {
+				title = parent + " / " + s.Title
+			}
+			for _, e := range s.Entries {
+				key := noteKey(e)
+				if _, ok := locations[key]; !ok {
+					keys = append(keys, key)
+				}
+				locations[key] = noteLocation{e, title}
+			}
+			add(s.Subsections, title)
+		}
+	}
+	add(sections, "")
+	return keys, locations
+}
+
+// noteKey identifies the PR of a release note, e.g. "#53233" or "kubernetes/kubectl#12".
+func noteKey(e NoteEntry) string {
+	return fmt.Sprintf("%s#%d", e.Repo, e.Number)
+}
+
+// diffNotes compares the release notes of two documents by PR. The deprecations and known issues
+// are compared on their own, as deprecations are usually in the note sections as well.
+func diffNotes(old, updated *ReleaseNotes) *notesDiff {
+	d := &notesDiff{}
+	d.add(old.Sections, updated.Sections)
+	d.add([]NoteSection{{Title: deprecationsTitle, Entries: old.Deprecations}},
+		[]NoteSection{{Title: deprecationsTitle, Entries: updated.Deprecations}})
+	d.add([]NoteSection{{Title: knownIssuesTitle, Entries: knownIssueEntries(old.KnownIssues)}},
+		[]NoteSection{{Title: knownIssuesTitle, Entries: knownIssueEntries(updated.KnownIssues)}})
+	return d
+}
+
+// add adds the differences between two versions of the same sections.
+func (d *notesDiff) add(old, updated []NoteSection) {
+	oldKeys, oldLocations := noteLocations(old)
+	newKeys, newLocations := noteLocations(updated)
+
+	for _, key := range newKeys {
+		n := newLocations[key]
+		o, ok := oldLocations[key]
+		if !ok {
+			d.Added = append(d.Added, diffEntry{Entry: n.entry, Section: n.section})
+			continue
+		}
+		if o.section != n.section {
+			d.Moved = append(d.Moved, diffEntry{Entry: n.entry, OldSection: o.section, Section: n.section})
+		}
+		if strings.TrimSpace(o.entry.Text) != strings.TrimSpace(n.entry.Text) {
+			d.Changed = append(d.Changed, diffEntry{Entry: n.entry, Section: n.section, OldText: o.entry.Text})
+		}
+	}
+	for _, key := range oldKeys {
+		if _, ok := newLocations[key]; !ok {
+			o := oldLocations[key]
+			d.Removed = append(d.Removed, diffEntry{Entry: o.entry, OldSection: o.section})
+		}
+	}
+}
+
+// knownIssueEntries converts input known issues into entries, so they can be compared like notes.
+func knownIssueEntries(issues []KnownIssue) []NoteEntry {
+	entries := make([]NoteEntry, 0, len(issues))
+	for _, i := range issues {
+		entries = append(entries, NoteEntry{Number: i.Number, Text: i.Title})
+	}
+	return entries
+}
+
+// empty returns true if both documents have the same release notes.
+func (d *notesDiff) empty() bool {
+	return len(d.Added)+len(d.Removed)+len(d.Moved)+len(d.Changed) == 0
+}
+
+// writeDiffText writes input diff in a human readable form.
+func writeDiffText(w io.Writer, d *notesDiff, oldName, newName string) error {
+	var b bytes.Buffer
+	b.WriteString(fmt.Sprintf("Release note changes from %s to %s:\n", oldName, newName))
+	if d.empty() {
+		b.WriteString("\nNo release note changes.\n")
+	}
+	oneLine := func(s string) string {
+		return strings.Replace(strings.TrimSpace(s), "\n", " ", -1)
+	}
+
+	if len(d.Added) > 0 {
+		b.WriteString(fmt.Sprintf("\nAdded (%d):\n", len(d.Added)))
+		for _, e := range d.Added {
+			b.WriteString(fmt.Sprintf("  + %s [%s] %s\n", noteKey(e.Entry), e.Section, oneLine(e.Entry.Text)))
+		}
+	}
+	if len(d.Removed) > 0 {
+		b.WriteString(fmt.Sprintf("\nRemoved (%d):\n", len(d.Removed)))
+		for _, e := range d.Removed {
+			b.WriteString(fmt.Sprintf("  - %s [%s] %s\n", noteKey(e.Entry), e.OldSection, oneLine(e.Entry.Text)))
+		}
+	}
+	if len(d.Moved) > 0 {
+		b.WriteString(fmt.Sprintf("\nMoved (%d):\n", len(d.Moved)))
+		for _, e := range d.Moved {
+			b.WriteString(fmt.Sprintf("  > %s: %s -> %s\n", noteKey(e.Entry), e.OldSection, e.Section))
+		}
+	}
+	if len(d.Changed) > 0 {
+		b.WriteString(fmt.Sprintf("\nChanged text (%d):\n", len(d.Changed)))
+		for _, e := range d.Changed {
+			b.WriteString(fmt.Sprintf("  ~ %s [%s]\n      old: %s\n      new: %s\n", noteKey(e.Entry), e.Section, oneLine(e.OldText), oneLine(e.Entry.Text)))
+		}
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// writeDiffMarkdown writes input diff as a markdown "Changes since draft" block, to be pasted in
+// a review of the regenerated notes.
+func writeDiffMarkdown(w io.Writer, d *notesDiff) error {
+	var b bytes.Buffer
+	b.WriteString("### Changes since draft\n\n")
+	if d.empty() {
+		b.WriteString("No release note changes.\n")
+	}
+	// Known issues have no author
+	entry := func(e NoteEntry) string {
+		if e.Author == "" {
+			return fmt.Sprintf("(%s)", noteKey(e))
+		}
+		return fmt.Sprintf("(%s, @%s)", noteKey(e), e.Author)
+	}
+	ref := func(e NoteEntry) string {
+		if e.Author == "" {
+			return noteKey(e)
+		}
+		return fmt.Sprintf("%s (@%s)", noteKey(e), e.Author)
+	}
+
+	if len(d.Added) > 0 {
+		b.WriteString("**Added**\n\n")
+		for _, e := range d.Added {
+			b.WriteString(fmt.Sprintf("* %s %s in _%s_\n", indent(2, strings.TrimSpace(e.Entry.Text)), entry(e.Entry), e.Section))
+		}
+		b.WriteString("\n")
+	}
+	if len(d.Removed) > 0 {
+		b.WriteString("**Removed**\n\n")
+		for _, e := range d.Removed {
+			b.WriteString(fmt.Sprintf("* %s %s from _%s_\n", indent(2, strings.TrimSpace(e.Entry.Text)), entry(e.Entry), e.OldSection))
+		}
+		b.WriteString("\n")
+	}
+	if len(d.Moved) > 0 {
+		b.WriteString("**Moved**\n\n")
+		for _, e := range d.Moved {
+			b.WriteString(fmt.Sprintf("* %s: _%s_ to _%s_\n", ref(e.Entry), e.OldSection, e.Section))
+		}
+		b.WriteString("\n")
+	}
+	if len(d.Changed) > 0 {
+		b.WriteString("**Changed**\n\n")
+		for _, e := range d.Changed {
+			b.WriteString(fmt.Sprintf("* %s in _%s_\n\n", ref(e.Entry), e.Section))
+			b.WriteString(fmt.Sprintf("  Before:\n\n  %s\n\n", indent(2, blockquote(e.OldText))))
+			b.WriteString(fmt.Sprintf("  After:\n\n  %s\n\n", indent(2, blockquote(e.Entry.Text))))
+		}
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// blockquote quotes input markdown text.
+func blockquote(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight("> "+l, " ")
+	}
+	return strings.Join(lines, "\n")
+}
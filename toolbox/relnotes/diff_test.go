@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestDiffNotes creates two release note documents: the second one has PR 4 added, PR 3
+// removed, PR 2 moved to the Bug Fixes SIG Node subsection and the text of PR 1 changed.
+func newTestDiffNotes() (*ReleaseNotes, *ReleaseNotes) {
+	old := &ReleaseNotes{Sections: []NoteSection{
+		{Title: "Action Required", Entries: []NoteEntry{{Number: 1, Author: "a", Text: "One."}}},
+		{Title: "Other notable changes", Entries: []NoteEntry{
+			{Number: 2, Author: "b", Text: "Two."},
+			{Number: 3, Author: "c", Text: "Three."},
+		}},
+	}}
+	updated := &ReleaseNotes{Sections: []NoteSection{
+		{Title: "Action Required", Entries: []NoteEntry{{Number: 1, Author: "a", Text: "One, curated.\n\nWith details."}}},
+		{Title: "Bug Fixes", Subsections: []NoteSection{
+			{Title: "SIG Node", Entries: []NoteEntry{{Number: 2, Author: "b", Text: "Two.\n"}}},
+		}},
+		{Title: "Other notable changes", Entries: []NoteEntry{{Number: 4, Author: "d", Text: "Four."}}},
+	}}
+	return old, updated
+}
+
+func TestDiffNotes(t *testing.T) {
+	old, updated := newTestDiffNotes()
+	d := diffNotes(old, updated)
+
+	tables := []struct {
+		kind    string
+		entries []diffEntry
+		want    []int
+	}{
+		{"Added", d.Added, []int{4}},
+		{"Removed", d.Removed, []int{3}},
+		{"Moved", d.Moved, []int{2}},
+		{"Changed", d.Changed, []int{1}},
+	}
+	for _, table := range tables {
+		var got []int
+		for _, e := range table.entries {
+			got = append(got, e.Entry.Number)
+		}
+		if !reflect.DeepEqual(got, table.want) {
+			t.Errorf("%s PRs were incorrect, want: %v, got: %v", table.kind, table.want, got)
+		}
+	}
+	if m := d.Moved[0]; m.OldSection != "Other notable changes" || m.Section != "Bug Fixes / SIG Node" {
+		t.Errorf("Moved sections were incorrect, got: %q -> %q", m.OldSection, m.Section)
+	}
+	if c := d.Changed[0]; c.OldText != "One." || c.Entry.Text != "One, curated.\n\nWith details." {
+		t.Errorf("Changed texts were incorrect, got: %q -> %q", c.OldText, c.Entry.Text)
+	}
+
+	// Same PR number in different repositories
+	old.Sections[1].Entries[1].Repo = "kubernetes/kubectl"
+	updated.Sections[2].Entries = append(updated.Sections[2].Entries, NoteEntry{Number: 3, Text: "Three."})
+	d = diffNotes(old, updated)
+	if len(d.Added) != 2 || len(d.Removed) != 1 || noteKey(d.Removed[0].Entry) != "kubernetes/kubectl#3" {
+		t.Errorf("Expected notes of different repositories to differ, got: %+v", d)
+	}
+
+	if d = diffNotes(old, old); !d.empty() {
+		t.Errorf("Expected no changes, got: %+v", d)
+	}
+
+	// Deprecations and known issues are compared on their own
+	old.Deprecations = []NoteEntry{{Number: 2, Author: "b", Text: "Two is deprecated."}}
+	old.KnownIssues = []KnownIssue{{Number: 10, Title: "Ten"}, {Number: 11, Title: "Eleven"}}
+	updated = &ReleaseNotes{Sections: old.Sections,
+		Deprecations: []NoteEntry{{Number: 2, Author: "b", Text: "Two is deprecated, use Four."}, {Number: 4, Author: "d", Text: "Four."}},
+		KnownIssues:  []KnownIssue{{Number: 10, Title: "Ten, again"}},
+	}
+	d = diffNotes(old, updated)
+	tables = []struct {
+		kind    string
+		entries []diffEntry
+		want    []int
+	}{
+		{"Added", d.Added, []int{4}},
+		{"Removed", d.Removed, []int{11}},
+		{"Moved", d.Moved, nil},
+		{"Changed", d.Changed, []int{2, 10}},
+	}
+	for _, table := range tables {
+		var got []int
+		for _, e := range table.entries {
+			got = append(got, e.Entry.Number)
+		}
+		if !reflect.DeepEqual(got, table.want) {
+			t.Errorf("%s deprecations and known issues were incorrect, want: %v, got: %v", table.kind, table.want, got)
+		}
+	}
+	if a := d.Added[0]; a.Section != deprecationsTitle {
+		t.Errorf("Added section was incorrect, want: %v, got: %v", deprecationsTitle, a.Section)
+	}
+	if r := d.Removed[0]; r.OldSection != knownIssuesTitle || r.Entry.Text != "Eleven" {
+		t.Errorf("Removed known issue was incorrect, got: %+v", r)
+	}
+}
+
+func TestWriteDiffText(t *testing.T) {
+	old, updated := newTestDiffNotes()
+	want := "Release note changes from old.json to new.json:\n" +
+		"\nAdded (1):\n  + #4 [Other notable changes] Four.\n" +
+		"\nRemoved (1):\n  - #3 [Other notable changes] Three.\n" +
+		"\nMoved (1):\n  > #2: Other notable changes -> Bug Fixes / SIG Node\n" +
+		"\nChanged text (1):\n  ~ #1 [Action Required]\n      old: One.\n      new: One, curated.  With details.\n"
+
+	var b bytes.Buffer
+	if err := writeDiffText(&b, diffNotes(old, updated), "old.json", "new.json"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if b.String() != want {
+		t.Errorf("Diff was incorrect, want:\n%s\ngot:\n%s", want, b.String())
+	}
+}
+
+func TestWriteDiffMarkdown(t *testing.T) {
+	old, updated := newTestDiffNotes()
+	want := "### Changes since draft\n\n" +
+		"**Added**\n\n* Four. (#4, @d) in _Other notable changes_\n\n" +
+		"**Removed**\n\n* Three. (#3, @c) from _Other notable changes_\n\n" +
+		"**Moved**\n\n* #2 (@b): _Other notable changes_ to _Bug Fixes / SIG Node_\n\n" +
+		"**Changed**\n\n* #1 (@a) in _Action Required_\n\n" +
+		"  Before:\n\n  > One.\n\n" +
+		"  After:\n\n  > One, curated.\n  >\n  > With details.\n\n"
+
+	var b bytes.Buffer
+	if err := writeDiffMarkdown(&b, diffNotes(old, updated)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if b.String() != want {
+		t.Errorf("Diff was incorrect, want:\n%s\ngot:\n%s", want, b.String())
+	}
+
+	b.Reset()
+	writeDiffMarkdown(&b, diffNotes(old, &ReleaseNotes{Sections: old.Sections, KnownIssues: []KnownIssue{{Number: 10, Title: "Ten"}}}))
+	if want := "### Changes since draft\n\n**Added**\n\n* Ten (#10) in _Known Issues_\n\n"; b.String() != want {
+		t.Errorf("Diff was incorrect, want:\n%s\ngot:\n%s", want, b.String())
+	}
+
+	b.Reset()
+	writeDiffMarkdown(&b, diffNotes(old, old))
+	if b.String() != "### Changes since draft\n\nNo release note changes.\n" {
+		t.Errorf("Diff was incorrect, got:\n%s", b.String())
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "relnotes-diff")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	old, updated := newTestDiffNotes()
+	oldFile, newFile := filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json")
+	for file, notes := range map[string]*ReleaseNotes{oldFile: old, newFile: updated} {
+		content, _ := json.Marshal(notes)
+		if err := ioutil.WriteFile(file, content, 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	tables := []struct {
+		args []string
+		code int
+		want string
+	}{
+		{[]string{oldFile, newFile}, 0, "Added (1):\n  + #4"},
+		{[]string{"--markdown", oldFile, newFile}, 0, "### Changes since draft\n"},
+		{[]string{oldFile}, 2, ""},
+		{[]string{oldFile, filepath.Join(dir, "missing.json")}, 1, ""},
+	}
+	for _, table := range tables {
+		var out, errOut bytes.Buffer
+		if code := runDiff(table.args, &out, &errOut); code != table.code {
+			t.Errorf("%v: Exit code was incorrect, want: %v, got: %v (%s)", table.args, table.code, code, errOut.String())
+		}
+		if !strings.Contains(out.String(), table.want) {
+			t.Errorf("%v: Output missing %q:\n%s", table.args, table.want, out.String())
+		}
+	}
+}
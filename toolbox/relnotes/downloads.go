@@ -0,0 +1,294 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/release/toolbox/util/checksum"
+)
+
+var (
+	// defaultDownloadGroups are the download tables of Kubernetes releases.
+	defaultDownloadGroups = []DownloadGroup{
+		{"", []string{"kubernetes.tar.gz", "kubernetes-src.tar.gz"}},
+		{"Client Binaries", []string{"kubernetes-client*.tar.gz"}},
+		{"Server Binaries", []string{"kubernetes-server*.tar.gz"}},
+		{"Node Binaries", []string{"kubernetes-node*.tar.gz"}},
+	}
+	// signatureExtensions and certificateExtensions are the extensions of the detached signatures
+	// and certificates of release artifacts, e.g. kubernetes.tar.gz.sig.
+	signatureExtensions   = []string{".sig", ".asc"}
+	certificateExtensions = []string{".cert", ".pem"}
+	// checksumExtensions are the extensions of per-file checksums, which aren't artifacts either.
+	checksumExtensions = []string{".md5", ".sha1", ".sha256", ".sha512"}
+	// shaSumsFile matches the names of SHA*SUMS files, plain or prefixed with a release, e.g.
+	// "SHA256SUMS" or "v1.8.1-SHA512SUMS".
+	shaSumsFile = regexp.MustCompile("^(.*-)?SHA.*SUMS$")
+)
+
+// DownloadsManifest describes the download tables of a release. Manifest files are YAML or JSON,
+// for example:
+//
+//     dir: _output/release-tars
+//     urlPrefix: https://dl.k8s.io
+//     groups:
+//     - files: [kubernetes.tar.gz, kubernetes-src.tar.gz]
+//     - heading: Client Binaries
+//       files: ["kubernetes-client*.tar.gz"]
+type DownloadsManifest struct {
+	// Dir is the directory of the artifacts, relative to the manifest file. It defaults to
+	// --release-tars, or the directory of the manifest file
+	Dir string `json:"dir,omitempty"`
+	// URLPrefix is the download URL of the artifacts, followed by /<release tag>/<file>
+	URLPrefix string `json:"urlPrefix,omitempty"`
+	// Groups defaults to defaultDownloadGroups
+	Groups []DownloadGroup `json:"groups,omitempty"`
+}
+
+// DownloadGroup is a download table, listing the artifacts matching its file name patterns.
+type DownloadGroup struct {
+	Heading string `json:"heading,omitempty"`
+	// Files are filepath.Match patterns of artifact file names
+	Files []string `json:"files"`
+}
+
+// Signed checks if any of the files of the table has a signature or certificate.
+func (t DownloadTable) Signed() bool {
+	for _, f := range t.Files {
+		if f.Signature != "" || f.Certificate != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// loadDownloadsManifest reads and validates a YAML or JSON downloads manifest file.
+func loadDownloadsManifest(filename string) (*DownloadsManifest, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read downloads manifest %s: %v", filename, err)
+	}
+	m := &DownloadsManifest{}
+	if err = yaml.Unmarshal(content, m); err != nil {
+		return nil, fmt.Errorf("failed to parse downloads manifest %s: %v", filename, err)
+	}
+
+	for _, g := range m.Groups {
+		if len(g.Files) == 0 {
+			return nil, fmt.Errorf("download group %q of %s lists no files", g.Heading, filename)
+		}
+		for _, pattern := range g.Files {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid file pattern %q in downloads manifest %s: %v", pattern, filename, err)
+			}
+		}
+	}
+	if m.Dir != "" && !filepath.IsAbs(m.Dir) {
+		m.Dir = filepath.Join(filepath.Dir(filename), m.Dir)
+	}
+	if m.Dir == "" {
+		m.Dir = filepath.Dir(filename)
+	}
+	return m, nil
+}
+
+// getDownloads creates the download tables of the release artifacts, with their hashes, signatures
+// and certificates. The artifacts are grouped as described by input manifest file if any, or by
+// defaultDownloadGroups. Artifacts are read from input directory, which takes precedence over the
+// directory of the manifest.
+func getDownloads(releaseTag, dir, manifestFile string) ([]DownloadTable, error) {
+	m := &DownloadsManifest{}
+	if manifestFile != "" {
+		var err error
+		if m, err = loadDownloadsManifest(manifestFile); err != nil {
+			return nil, err
+		}
+	}
+	if dir != "" {
+		m.Dir = dir
+	}
+	if len(m.Groups) == 0 {
+		m.Groups = defaultDownloadGroups
+	}
+	if m.URLPrefix == "" {
+		m.URLPrefix = downloadURLPrefix()
+	}
+
+	files, err := scanArtifacts(m.Dir)
+	if err != nil {
+		return nil, err
+	}
+	return createDownloadTables(releaseTag, m, files)
+}
+
+// downloadURLPrefix returns the download URL of the artifacts, from --download-url or the
+// --release-bucket Google Storage bucket.
+func downloadURLPrefix() string {
+	if *downloadURL != "" {
+		return strings.TrimSuffix(*downloadURL, "/")
+	}
+	if *releaseBucket == "" {
+		log.Print("NOTE: empty Google Storage bucket specified. Please specify valid bucket using \"release-bucket\" flag.")
+	}
+	if *releaseBucket == "kubernetes-release" {
+		return k8sReleaseURLPrefix
+	}
+	return fmt.Sprintf("https://storage.googleapis.com/%s/release", *releaseBucket)
+}
+
+// scanArtifacts lists the files of input directory, sorted by name.
+func scanArtifacts(dir string) ([]string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list release artifacts: %v", err)
+	}
+	files := make([]string, 0, len(infos))
+	for _, info := range infos {
+		if !info.IsDir() {
+			files = append(files, filepath.Join(dir, info.Name()))
+		}
+	}
+	return files, nil
+}
+
+// createDownloadTables creates a download table per group of input manifest, listing the matching
+// artifacts in pattern order. Each artifact is listed in the first matching group only, and
+// signatures, certificates and checksum files are listed along with their artifact. The artifacts
+// are hashed in parallel.
+func createDownloadTables(releaseTag string, m *DownloadsManifest, files []string) ([]DownloadTable, error) {
+	names := make(map[string]bool)
+	for _, f := range files {
+		names[filepath.Base(f)] = true
+	}
+
+	listed := make(map[string]bool)
+	groups := make([][]string, len(m.Groups))
+	artifacts := make([]string, 0)
+	for i, g := range m.Groups {
+		for _, pattern := range g.Files {
+			for _, file := range files {
+				name := filepath.Base(file)
+				if listed[name] || isAuxiliaryFile(name, names) {
+					continue
+				}
+				if ok, _ := filepath.Match(pattern, name); !ok {
+					continue
+				}
+				listed[name] = true
+				groups[i] = append(groups[i], file)
+				artifacts = append(artifacts, file)
+			}
+		}
+	}
+	for _, file := range files {
+		name := filepath.Base(file)
+		if !listed[name] && !isAuxiliaryFile(name, names) {
+			log.Printf("NOTE: %s matches no download group, skipping it", name)
+		}
+	}
+
+	digests, err := checksum.Files(artifacts, 0, checksum.SHA256, checksum.SHA512)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calc hashes of release artifacts: %v", err)
+	}
+	tables := make([]DownloadTable, 0, len(m.Groups))
+	for i, g := range m.Groups {
+		table := DownloadTable{Heading: g.Heading, Files: make([]DownloadFile, 0)}
+		for _, file := range groups[i] {
+			table.Files = append(table.Files, newDownloadFile(releaseTag, m.URLPrefix, file, digests[file], names))
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// newDownloadFile creates the download of input artifact file. Its signature and certificate
+// are the files of the same name with a signature or certificate extension, if any.
+func newDownloadFile(releaseTag, urlPrefix, file string, digests checksum.Digests, names map[string]bool) DownloadFile {
+	name := filepath.Base(file)
+	url := func(name string) string {
+		return fmt.Sprintf("%s/%s/%s", urlPrefix, releaseTag, name)
+	}
+
+	d := DownloadFile{Name: name, URL: url(name), SHA256: digests[checksum.SHA256], SHA512: digests[checksum.SHA512]}
+	for _, ext := range signatureExtensions {
+		if names[name+ext] && d.Signature == "" {
+			d.Signature = url(name + ext)
+		}
+	}
+	for _, ext := range certificateExtensions {
+		if names[name+ext] && d.Certificate == "" {
+			d.Certificate = url(name + ext)
+		}
+	}
+	return d
+}
+
+// isAuxiliaryFile checks if input file name is a signature, certificate or checksum of another
+// file, or a SHA*SUMS file such as the <release>-SHA256SUMS files of writeChecksumFiles, which
+// aren't listed as artifacts.
+func isAuxiliaryFile(name string, names map[string]bool) bool {
+	if shaSumsFile.MatchString(name) {
+		return true
+	}
+	for _, exts := range [][]string{signatureExtensions, certificateExtensions, checksumExtensions} {
+		for _, ext := range exts {
+			if strings.HasSuffix(name, ext) && names[strings.TrimSuffix(name, ext)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeChecksumFiles writes the SHA256SUMS and SHA512SUMS files of input downloads in directory
+// dir, in the format of sha256sum and sha512sum. The files are prefixed with the release tag, e.g.
+// v1.8.1-SHA256SUMS, so that the files of different releases don't overwrite each other.
+func writeChecksumFiles(dir, releaseTag string, downloads []DownloadTable) error {
+	files := make([]DownloadFile, 0)
+	for _, t := range downloads {
+		files = append(files, t.Files...)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	sums := []struct {
+		name string
+		hash func(f DownloadFile) string
+	}{
+		{releaseTag + "-SHA256SUMS", func(f DownloadFile) string { return f.SHA256 }},
+		{releaseTag + "-SHA512SUMS", func(f DownloadFile) string { return f.SHA512 }},
+	}
+	for _, s := range sums {
+		var b bytes.Buffer
+		for _, f := range files {
+			b.WriteString(fmt.Sprintf("%s  %s\n", s.hash(f), f.Name))
+		}
+		filename := filepath.Join(dir, s.name)
+		if err := ioutil.WriteFile(filename, b.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %v", filename, err)
+		}
+		log.Printf("Wrote %s", filename)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	sha256OfA = "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"
+	sha512OfA = "1f40fc92da241694750979ee6cf582f2d5d7d28e18335de05abc54d0560e0f5302860c652bf08d560252aa5e74210546f369fbbbce8c12cfc7957b2652fe9a75"
+	sha256OfB = "3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d"
+	sha512OfB = "5267768822ee624d48fce15ec5ca79cbd602cb7f4c2157a516556991f22ef8c7b5ef7b18d1ff41c59370efb0858651d44a936c11b7b144c48fe04df3c6a3e8da"
+)
+
+// newTestArtifacts creates a release-tars directory with signed artifacts, checksum files and an
+// artifact matching none of the default download groups.
+func newTestArtifacts(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "relnotes-downloads")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	files := map[string]string{
+		"kubernetes.tar.gz":                           "a",
+		"kubernetes.tar.gz.sig":                       "signature",
+		"kubernetes.tar.gz.cert":                      "certificate",
+		"kubernetes-src.tar.gz":                       "b",
+		"kubernetes-client-linux-amd64.tar.gz":        "a",
+		"kubernetes-client-linux-amd64.tar.gz.sha256": sha256OfA,
+		"kubernetes-client-darwin-amd64.tar.gz":       "b",
+		"kubernetes-client-darwin-amd64.tar.gz.asc":   "signature",
+		"kubernetes-server-linux-amd64.tar.gz":        "a",
+		"kubernetes-manifests.tar.gz":                 "a",
+		"SHA256SUMS":                                  "",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestGetDownloads(t *testing.T) {
+	dir := newTestArtifacts(t)
+	defer os.RemoveAll(dir)
+
+	downloads, err := getDownloads("v1.8.1", dir, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var headings []string
+	names := make(map[string][]string)
+	for _, table := range downloads {
+		headings = append(headings, table.Heading)
+		for _, f := range table.Files {
+			names[table.Heading] = append(names[table.Heading], f.Name)
+		}
+	}
+	if want := []string{"", "Client Binaries", "Server Binaries", "Node Binaries"}; !reflect.DeepEqual(headings, want) {
+		t.Errorf("Headings were incorrect, want: %v, got: %v", want, headings)
+	}
+	wantNames := map[string][]string{
+		"":                {"kubernetes.tar.gz", "kubernetes-src.tar.gz"},
+		"Client Binaries": {"kubernetes-client-darwin-amd64.tar.gz", "kubernetes-client-linux-amd64.tar.gz"},
+		"Server Binaries": {"kubernetes-server-linux-amd64.tar.gz"},
+	}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("Files were incorrect, want: %v, got: %v", wantNames, names)
+	}
+
+	want := DownloadFile{
+		Name:        "kubernetes.tar.gz",
+		URL:         "https://dl.k8s.io/v1.8.1/kubernetes.tar.gz",
+		SHA256:      sha256OfA,
+		SHA512:      sha512OfA,
+		Signature:   "https://dl.k8s.io/v1.8.1/kubernetes.tar.gz.sig",
+		Certificate: "https://dl.k8s.io/v1.8.1/kubernetes.tar.gz.cert",
+	}
+	if got := downloads[0].Files[0]; got != want {
+		t.Errorf("Download was incorrect, want: %+v, got: %+v", want, got)
+	}
+	if got := downloads[1].Files[0]; got.Signature != "https://dl.k8s.io/v1.8.1/kubernetes-client-darwin-amd64.tar.gz.asc" || got.Certificate != "" {
+		t.Errorf("Signature was incorrect, got: %+v", got)
+	}
+	if !downloads[1].Signed() || downloads[2].Signed() {
+		t.Errorf("Signed tables were incorrect, want: Client Binaries, got: %v %v", downloads[1].Signed(), downloads[2].Signed())
+	}
+}
+
+func TestGetDownloadsManifest(t *testing.T) {
+	dir := newTestArtifacts(t)
+	defer os.RemoveAll(dir)
+
+	manifest := filepath.Join(dir, "downloads.yaml")
+	content := "urlPrefix: https://example.com/releases\ngroups:\n- heading: Linux\n  files: [\"*linux*\"]\n- heading: Other\n  files: [\"*.tar.gz\"]\n"
+	if err := ioutil.WriteFile(manifest, []byte(content), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	downloads, err := getDownloads("v1.8.1", "", manifest)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(downloads) != 2 || len(downloads[0].Files) != 2 || len(downloads[1].Files) != 4 {
+		t.Fatalf("Downloads were incorrect, want: 2 linux and 4 other files, got: %+v", downloads)
+	}
+	if got, want := downloads[0].Files[0].URL, "https://example.com/releases/v1.8.1/kubernetes-client-linux-amd64.tar.gz"; got != want {
+		t.Errorf("URL was incorrect, want: %v, got: %v", want, got)
+	}
+
+	for _, content := range []string{
+		"groups:\n- heading: Empty\n",
+		"groups:\n- files: [\"[\"]\n",
+	} {
+		ioutil.WriteFile(manifest, []byte(content), 0644)
+		if _, err := loadDownloadsManifest(manifest); err == nil {
+			t.Errorf("%q: Expected error", content)
+		}
+	}
+}
+
+func TestGetDownloadsRescan(t *testing.T) {
+	dir := newTestArtifacts(t)
+	defer os.RemoveAll(dir)
+
+	// A broad pattern matches the checksum files written in the artifacts directory
+	manifest := filepath.Join(dir, "downloads.yaml")
+	if err := ioutil.WriteFile(manifest, []byte("groups:\n- files: [\"*\"]\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	first, err := getDownloads("v1.8.1", "", manifest)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err = writeChecksumFiles(dir, "v1.8.1", first); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	second, err := getDownloads("v1.8.1", "", manifest)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Downloads of the second scan were incorrect, want: %+v, got: %+v", first, second)
+	}
+	for _, f := range second[0].Files {
+		if strings.HasSuffix(f.Name, "SUMS") {
+			t.Errorf("Checksum file %s was listed as an artifact", f.Name)
+		}
+	}
+}
+
+func TestWriteChecksumFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "relnotes-checksums")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	downloads := []DownloadTable{
+		{"", []DownloadFile{{Name: "kubernetes.tar.gz", SHA256: sha256OfA, SHA512: sha512OfA}}},
+		{"Client Binaries", []DownloadFile{{Name: "kubernetes-client.tar.gz", SHA256: sha256OfB, SHA512: sha512OfB}}},
+	}
+	if err := writeChecksumFiles(dir, "v1.8.1", downloads); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tables := []struct {
+		name string
+		want string
+	}{
+		{"v1.8.1-SHA256SUMS", sha256OfB + "  kubernetes-client.tar.gz\n" + sha256OfA + "  kubernetes.tar.gz\n"},
+		{"v1.8.1-SHA512SUMS", sha512OfB + "  kubernetes-client.tar.gz\n" + sha512OfA + "  kubernetes.tar.gz\n"},
+	}
+	for _, table := range tables {
+		content, err := ioutil.ReadFile(filepath.Join(dir, table.name))
+		if err != nil {
+			t.Errorf("%s: Unexpected error: %v", table.name, err)
+			continue
+		}
+		if string(content) != table.want {
+			t.Errorf("%s was incorrect, want:\n%s\ngot:\n%s", table.name, table.want, content)
+		}
+	}
+}
+
+func TestDownloadsBlockSigned(t *testing.T) {
+	notes := &ReleaseNotes{
+		Title: "v1.8.1",
+		Downloads: []DownloadTable{{"Client Binaries", []DownloadFile{
+			{Name: "a.tar.gz", URL: "https://dl.k8s.io/v1.8.1/a.tar.gz", SHA256: "1", SHA512: "2",
+				Signature: "https://dl.k8s.io/v1.8.1/a.tar.gz.sig", Certificate: "https://dl.k8s.io/v1.8.1/a.tar.gz.cert"},
+			{Name: "b.tar.gz", URL: "https://dl.k8s.io/v1.8.1/b.tar.gz", SHA256: "3", SHA512: "4"},
+		}}},
+	}
+
+	var b bytes.Buffer
+	if err := renderBlock(&b, "downloads", notes); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := "filename | sha256 hash | sha512 hash | signature\n" +
+		"-------- | ----------- | ----------- | ---------\n" +
+		"[a.tar.gz](https://dl.k8s.io/v1.8.1/a.tar.gz) | `1` | `2` | " +
+		"[signature](https://dl.k8s.io/v1.8.1/a.tar.gz.sig), [certificate](https://dl.k8s.io/v1.8.1/a.tar.gz.cert)\n" +
+		"[b.tar.gz](https://dl.k8s.io/v1.8.1/b.tar.gz) | `3` | `4` | \n"
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("Downloads missing %q:\n%s", want, b.String())
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+	u "k8s.io/release/toolbox/util"
+)
+
+// enhancementStages maps the stage labels of enhancement issues to the subsections of "Major
+// Themes by stage", in display order. Enhancements without stage label go in a last "Other"
+// subsection.
+var enhancementStages = []struct {
+	label string
+	title string
+}{
+	{"stage/stable", "Stable"},
+	{"stage/beta", "Beta"},
+	{"stage/alpha", "Alpha"},
+}
+
+// EnhancementStage lists the enhancements of a release graduating to a stage.
+type EnhancementStage struct {
+	Title        string        `json:"title"`
+	Enhancements []Enhancement `json:"enhancements"`
+}
+
+// Enhancement is an enhancement (features repository issue) tracked in the release milestone,
+// and the release notes of the PRs implementing it.
+type Enhancement struct {
+	Number  int         `json:"number"`
+	Title   string      `json:"title"`
+	URL     string      `json:"url"`
+	Stage   string      `json:"stage,omitempty"`
+	Entries []NoteEntry `json:"entries"`
+}
+
+// listEnhancements lists the issues of the features repository (as owner/repo) tracked in given
+// milestone.
+func listEnhancements(g *u.GithubClient, featuresRepo, milestone string) ([]github.Issue, error) {
+	log.Printf("Getting %s enhancements of milestone %s...", featuresRepo, milestone)
+
+	var query []string
+	query = u.AddQuery(query, "repo", featuresRepo)
+	query = u.AddQuery(query, "type", "issue")
+	query = u.AddQuery(query, "milestone", "\"", milestone, "\"")
+	issues, err := g.SearchIssues(strings.Join(query, " "))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search enhancements: %v", err)
+	}
+	return issues, nil
+}
+
+// linkEnhancements groups the enhancements of a release by stage, with the release notes of the
+// PRs implementing them. A PR implements an enhancement if its description references the
+// enhancement issue (e.g. "kubernetes/features#421"), or if the enhancement issue references the
+// PR (e.g. "kubernetes/kubernetes#53233" or its URL).
+func linkEnhancements(issues []github.Issue, info *ReleaseInfo, featuresRepo, repo string) []EnhancementStage {
+	featureReference := regexp.MustCompile("(?:" + regexp.QuoteMeta(featuresRepo) + "#|" +
+		regexp.QuoteMeta("github.com/"+featuresRepo+"/issues/") + ")([0-9]+)\\b")
+	prReference := regexp.MustCompile("(?:" + regexp.QuoteMeta(repo) + "#|" +
+		regexp.QuoteMeta("github.com/"+repo+"/pull/") + ")([0-9]+)\\b")
+
+	// Features referenced by each PR, in release order
+	prs := make([]int, 0)
+	actionRequired := make(map[int]bool)
+	for _, pr := range info.releaseActionRequiredPRs {
+		prs = append(prs, pr)
+		actionRequired[pr] = true
+	}
+	prs = append(prs, info.releasePRs...)
+	implements := make(map[int]map[int]bool)
+	for _, pr := range prs {
+		implements[pr] = references(featureReference, info.prMap[pr].GetBody())
+	}
+
+	stages := make([]EnhancementStage, 0, len(enhancementStages)+1)
+	for _, s := range enhancementStages {
+		stages = append(stages, EnhancementStage{Title: s.title, Enhancements: make([]Enhancement, 0)})
+	}
+	other := EnhancementStage{Title: "Other", Enhancements: make([]Enhancement, 0)}
+
+	for _, i := range issues {
+		e := Enhancement{Number: i.GetNumber(), Title: i.GetTitle(), URL: i.GetHTMLURL(), Entries: make([]NoteEntry, 0)}
+		implementedBy := references(prReference, i.GetBody())
+		for _, pr := range prs {
+			if implements[pr][e.Number] || implementedBy[pr] {
+				e.Entries = append(e.Entries, newNoteEntry(info.prMap[pr], actionRequired[pr]))
+			}
+		}
+
+		stage := -1
+		for j, s := range enhancementStages {
+			if u.HasLabel(&i, s.label) {
+				stage = j
+				break
+			}
+		}
+		if stage == -1 {
+			other.Enhancements = append(other.Enhancements, e)
+			continue
+		}
+		e.Stage = strings.ToLower(enhancementStages[stage].title)
+		stages[stage].Enhancements = append(stages[stage].Enhancements, e)
+	}
+	stages = append(stages, other)
+
+	// Only keep the stages with enhancements
+	nonEmpty := make([]EnhancementStage, 0, len(stages))
+	for _, s := range stages {
+		if len(s.Enhancements) > 0 {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+	return nonEmpty
+}
+
+// references returns the numbers matched by the first group of input reference regexp.
+func references(reference *regexp.Regexp, s string) map[int]bool {
+	numbers := make(map[int]bool)
+	for _, m := range reference.FindAllStringSubmatch(s, -1) {
+		if n, err := strconv.Atoi(m[1]); err == nil {
+			numbers[n] = true
+		}
+	}
+	return numbers
+}
+
+// gatherEnhancements fills the enhancements of a minor release, tracked in the milestone of the
+// release in the features repository (see releaseMilestone). The enhancements are linked to the
+// PRs of info, so the range of a vX.Y.0 release must cover the whole minor release.
+func gatherEnhancements(g *u.GithubClient, info *ReleaseInfo, notes *ReleaseNotes) error {
+	if err := checkMinorRange(info); err != nil {
+		return fmt.Errorf("failed to gather enhancements: %v", err)
+	}
+	milestone, err := releaseMilestone(info)
+	if err != nil {
+		return err
+	}
+	issues, err := listEnhancements(g, *featuresRepo, milestone)
+	if err != nil {
+		return err
+	}
+	notes.Enhancements = linkEnhancements(issues, info, *featuresRepo, *owner+"/"+*repo)
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/github"
+)
+
+// newTestEnhancements creates the features repository issues of the v1.8 milestone: a stable one
+// referencing PR 53233, a beta one referenced by PR 52602 and an alpha one without PR.
+func newTestEnhancements() []github.Issue {
+	issue := func(number int, title, body string, labels ...string) github.Issue {
+		i := *newTestPR(number, "", title, body, labels...)
+		i.HTMLURL = github.String("https://github.com/kubernetes/features/issues/" + strings.TrimPrefix(title, "F"))
+		return i
+	}
+	return []github.Issue{
+		issue(1, "F1", "Implemented in kubernetes/kubernetes#53233.", "stage/stable", "sig/node"),
+		issue(2, "F2", "", "stage/beta"),
+		issue(3, "F3", "See https://github.com/kubernetes/kubernetes/pull/1234", "stage/alpha"),
+		issue(4, "F4", "", "sig/auth"),
+	}
+}
+
+func TestLinkEnhancements(t *testing.T) {
+	info := newTestReleaseInfo()
+	info.prMap[52602].Body = github.String(*info.prMap[52602].Body + "\r\nFeature: https://github.com/kubernetes/features/issues/2")
+	info.prMap[53317].Body = github.String("For kubernetes/features#24 and kubernetes/kubernetes#1")
+
+	stages := linkEnhancements(newTestEnhancements(), info, "kubernetes/features", "kubernetes/kubernetes")
+
+	type enhancement struct {
+		stage string
+		prs   []int
+	}
+	got := make(map[int]enhancement)
+	var titles []string
+	for _, s := range stages {
+		titles = append(titles, s.Title)
+		for _, e := range s.Enhancements {
+			var prs []int
+			for _, n := range e.Entries {
+				prs = append(prs, n.Number)
+			}
+			got[e.Number] = enhancement{e.Stage, prs}
+		}
+	}
+
+	if want := []string{"Stable", "Beta", "Alpha", "Other"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("Stages were incorrect, want: %v, got: %v", want, titles)
+	}
+	want := map[int]enhancement{
+		1: {"stable", []int{53233}},
+		2: {"beta", []int{52602}},
+		3: {"alpha", nil},
+		4: {"", nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Enhancements were incorrect, want: %v, got: %v", want, got)
+	}
+	if e := stages[1].Enhancements[0].Entries[0]; !e.ActionRequired {
+		t.Errorf("Expected #52602 to be action required, got: %+v", e)
+	}
+
+	if stages = linkEnhancements(newTestEnhancements()[3:], info, "kubernetes/features", "kubernetes/kubernetes"); len(stages) != 1 {
+		t.Errorf("Expected empty stages to be dropped, got: %+v", stages)
+	}
+}
+
+func TestWriteMarkdownEnhancements(t *testing.T) {
+	notes := &ReleaseNotes{
+		Title:   "v1.8.0",
+		Version: "v1.8.0",
+		Minor:   true,
+		Draft:   "## Major Themes\n\n* Workloads API goes beta\n",
+		Enhancements: linkEnhancements(newTestEnhancements()[:2], newTestReleaseInfo(),
+			"kubernetes/features", "kubernetes/kubernetes"),
+	}
+
+	var b bytes.Buffer
+	if err := writeMarkdown(&b, notes); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := "* Workloads API goes beta\n\n" +
+		"## Major Themes by stage\n\n" +
+		"### Stable\n\n* F1 ([#1](https://github.com/kubernetes/features/issues/1))\n" +
+		"  * Fixes a performance issue when deleting pods. (#53233, @liggitt)\n\n" +
+		"### Beta\n\n* F2 ([#2](https://github.com/kubernetes/features/issues/2))\n\n" +
+		"### Previous Release Included in v1.8.0\n"
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("Markdown missing %q:\n%s", want, b.String())
+	}
+
+	b.Reset()
+	if err := writeText(&b, notes); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want = "Major Themes by stage\n---------------------\n\n" +
+		"Stable:\n- F1 (#1)\n  https://github.com/kubernetes/features/issues/1\n" +
+		"  - Fixes a performance issue when deleting pods. (#53233, @liggitt)\n\n"
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("Text missing %q:\n%s", want, b.String())
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	u "k8s.io/release/toolbox/util"
+)
+
+// fileFetcher gets the content of a file in the repository at a given ref. If the file doesn't
+// exist at ref, the error satisfies os.IsNotExist.
+type fileFetcher func(path, ref string) ([]byte, error)
+
+// newFileFetcher returns a fileFetcher reading from the local clone at repoDir, or from the
+// Github contents API if repoDir is empty.
+func newFileFetcher(g *u.GithubClient, owner, repo, repoDir string) fileFetcher {
+	if repoDir != "" {
+		return func(path, ref string) ([]byte, error) {
+			if _, err := u.RevParse(repoDir, ref+":"+path); err != nil {
+				if _, err = u.RevParse(repoDir, ref+"^{commit}"); err != nil {
+					return nil, fmt.Errorf("failed to resolve %s: %v", ref, err)
+				}
+				return nil, &os.PathError{Op: "show", Path: ref + ":" + path, Err: os.ErrNotExist}
+			}
+			// Unlike u.Git, keep the content as is, without stderr
+			content, err := exec.Command("git", "-C", repoDir, "show", ref+":"+path).Output()
+			if err != nil {
+				return nil, fmt.Errorf("failed to show %s at %s: %v", path, ref, err)
+			}
+			return content, nil
+		}
+	}
+	return func(path, ref string) ([]byte, error) {
+		return g.GetFileContent(owner, repo, path, ref)
+	}
+}
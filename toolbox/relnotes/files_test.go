@@ -0,0 +1,42 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	u "k8s.io/release/toolbox/util"
+	"k8s.io/release/toolbox/util/testutil"
+)
+
+func TestNewFileFetcher(t *testing.T) {
+	dir, cleanup := testutil.SetupGitRepos(t)
+	defer cleanup()
+
+	content := "  indented\ntrailing spaces  \n\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "file.txt"), []byte(content), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, c := range [][]string{{"add", "file.txt"}, {"commit", "-m", "Add file"}, {"tag", "v1.8.0"}} {
+		if _, err := u.Git(dir, c...); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	fetch := newFileFetcher(nil, "kubernetes", "kubernetes", dir)
+	got, err := fetch("file.txt", "v1.8.0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(got) != content {
+		t.Errorf("Content was incorrect, want: %q, got: %q", content, got)
+	}
+
+	if _, err = fetch("missing.txt", "v1.8.0"); !os.IsNotExist(err) {
+		t.Errorf("Expected not exist error for missing file, got: %v", err)
+	}
+	if _, err = fetch("file.txt", "v9.9.9"); err == nil || os.IsNotExist(err) {
+		t.Errorf("Expected error for missing ref, got: %v", err)
+	}
+}
@@ -0,0 +1,480 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// markdownLink matches markdown links, e.g. "[#53233](https://github.com/.../pull/53233)".
+	markdownLink = regexp.MustCompile("\\[([^\\]]*)\\]\\(([^)]*)\\)")
+	// markdownBold matches markdown bold text, e.g. "**Important**".
+	markdownBold = regexp.MustCompile("\\*\\*([^*]+)\\*\\*")
+	// mrkdwnEscaper escapes the control characters of chat mrkdwn text.
+	mrkdwnEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+)
+
+// writeText renders input release note as plain text.
+func writeText(w io.Writer, notes *ReleaseNotes) error {
+	var b bytes.Buffer
+	underline := func(s string, c string) {
+		b.WriteString(s + "\n" + strings.Repeat(c, len(s)) + "\n\n")
+	}
+
+	underline(notes.Title, "=")
+	b.WriteString(fmt.Sprintf("Documentation: %s\nExamples: %s\n\n", notes.DocURL, notes.ExampleURL))
+
+	if len(notes.Downloads) > 0 {
+		underline("Downloads for "+notes.Title, "-")
+		for _, table := range notes.Downloads {
+			for _, f := range table.Files {
+				b.WriteString(fmt.Sprintf("%s\n  %s\n  sha256: %s\n  sha512: %s\n", f.Name, f.URL, f.SHA256, f.SHA512))
+				if f.Signature != "" {
+					b.WriteString(fmt.Sprintf("  signature: %s\n", f.Signature))
+				}
+				if f.Certificate != "" {
+					b.WriteString(fmt.Sprintf("  certificate: %s\n", f.Certificate))
+				}
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if notes.Minor {
+		if notes.Draft != "" {
+			b.WriteString(plainText(notes.Draft) + "\n\n")
+		} else if len(notes.KnownIssues)+len(notes.Deprecations) > 0 {
+			underline("Known Issues", "-")
+			for _, i := range notes.KnownIssues {
+				b.WriteString(fmt.Sprintf("- %s (#%d)\n  %s\n", i.Title, i.Number, i.URL))
+			}
+			b.WriteString("\n")
+			writeTextSection(&b, NoteSection{Title: deprecationsTitle, Entries: notes.Deprecations}, "")
+		}
+		if len(notes.Enhancements) > 0 {
+			underline("Major Themes by stage", "-")
+			for _, s := range notes.Enhancements {
+				b.WriteString(s.Title + ":\n")
+				for _, e := range s.Enhancements {
+					b.WriteString(fmt.Sprintf("- %s (#%d)\n  %s\n", e.Title, e.Number, e.URL))
+					for _, n := range e.Entries {
+						text := strings.Replace(plainText(n.Text), "\n", "\n    ", -1)
+						b.WriteString(fmt.Sprintf("  - %s (%s#%d, @%s)\n", text, n.Repo, n.Number, n.Author))
+					}
+				}
+				b.WriteString("\n")
+			}
+		}
+		underline("Previous Release Included in "+notes.Version, "-")
+		for _, r := range notes.PreviousReleases {
+			b.WriteString(plainText(r) + "\n")
+		}
+		b.WriteString("\n")
+	}
+	if len(notes.Repos) > 0 {
+		underline("Totals", "-")
+		for _, r := range repoSummaries(notes) {
+			b.WriteString(fmt.Sprintf("%s: %d release notes, %d action required, %d contributors\n", repoRange(r), r.Notes, r.ActionRequired, r.Contributors))
+		}
+		b.WriteString("\n")
+		underline("Changelog", "-")
+		for _, s := range notes.Sections {
+			writeTextSection(&b, s, "")
+		}
+	} else if !notes.Minor || hasEntries(notes.Sections) {
+		underline("Changelog since "+notes.StartTag, "-")
+		if !hasEntries(notes.Sections) {
+			b.WriteString("No notable changes for this release.\n\n")
+		}
+		for _, s := range notes.Sections {
+			writeTextSection(&b, s, "")
+		}
+	}
+
+	if notes.APIChanges != nil {
+		underline("API Changes", "-")
+		for _, c := range apiChangeLists(notes.APIChanges) {
+			for _, e := range c.entries {
+				b.WriteString(fmt.Sprintf("- %s: %s\n", c.heading, e))
+			}
+		}
+		b.WriteString("\n")
+	}
+	if notes.Dependencies != nil {
+		underline("Dependencies", "-")
+		if notes.Dependencies.ManifestChange != "" {
+			b.WriteString(fmt.Sprintf("The dependency manifest changed (%s), versions are compared across manifests.\n", notes.Dependencies.ManifestChange))
+		}
+		for _, d := range notes.Dependencies.Added {
+			b.WriteString(fmt.Sprintf("- Added %s %s\n", d.Name, shortVersion(d.NewVersion)))
+		}
+		for _, d := range notes.Dependencies.Changed {
+			b.WriteString(fmt.Sprintf("- Changed %s %s -> %s\n", d.Name, shortVersion(d.OldVersion), shortVersion(d.NewVersion)))
+		}
+		for _, d := range notes.Dependencies.Removed {
+			b.WriteString(fmt.Sprintf("- Removed %s %s\n", d.Name, shortVersion(d.OldVersion)))
+		}
+		b.WriteString("\n")
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// writeTextSection writes a release note section, and its subsections, as plain text.
+func writeTextSection(b *bytes.Buffer, s NoteSection, indent string) {
+	if !hasEntries([]NoteSection{s}) {
+		return
+	}
+	b.WriteString(indent + s.Title + ":\n")
+	for _, e := range s.Entries {
+		text := strings.Replace(plainText(e.Text), "\n", "\n"+indent+"  ", -1)
+		b.WriteString(fmt.Sprintf("%s- %s (%s#%d, @%s)\n", indent, text, e.Repo, e.Number, e.Author))
+	}
+	for _, sub := range s.Subsections {
+		writeTextSection(b, sub, indent+"  ")
+	}
+	if indent == "" {
+		b.WriteString("\n")
+	}
+}
+
+// repoSummaries returns the summaries of the repositories of a --manifest release, followed by
+// the totals.
+func repoSummaries(notes *ReleaseNotes) []RepoSummary {
+	summaries := make([]RepoSummary, 0)
+	summaries = append(summaries, notes.Repos...)
+	if notes.Totals != nil {
+		summaries = append(summaries, *notes.Totals)
+	}
+	return summaries
+}
+
+// repoRange displays the repository and release range of input summary, e.g.
+// "kubernetes/kubectl v0.1.0..v0.2.0".
+func repoRange(r RepoSummary) string {
+	if r.Version == "" {
+		return r.Repo
+	}
+	return fmt.Sprintf("%s %s..%s", r.Repo, r.StartTag, r.Version)
+}
+
+// plainText strips the inline markdown of input text: links are replaced by their text, and
+// emphasis and code markers are removed.
+func plainText(s string) string {
+	s = markdownLink.ReplaceAllString(s, "$1")
+	s = markdownBold.ReplaceAllString(s, "$1")
+	return strings.Replace(s, "`", "", -1)
+}
+
+// apiChangeList is a titled list of API changes.
+type apiChangeList struct {
+	heading string
+	entries []string
+}
+
+// apiChangeLists returns the non-empty lists of input API changes.
+func apiChangeLists(c *APIChanges) []apiChangeList {
+	lists := make([]apiChangeList, 0)
+	for _, l := range []apiChangeList{
+		{"New API Group Version", c.AddedGroupVersions},
+		{"Removed API Group Version", c.RemovedGroupVersions},
+		{"New Kind", c.AddedKinds},
+		{"Removed Kind", c.RemovedKinds},
+		{"New Field", c.AddedFields},
+		{"Removed Field", c.RemovedFields},
+		{"Deprecation", c.Deprecations},
+	} {
+		if len(l.entries) > 0 {
+			lists = append(lists, l)
+		}
+	}
+	return lists
+}
+
+// chatMessage is a chat webhook payload, e.g. for Slack incoming webhooks.
+type chatMessage struct {
+	Text string `json:"text"`
+}
+
+// writeChat renders input release note as a JSON list of chat messages, using the mrkdwn markup
+// of Slack-style chats. The notes are split so that each message is at most maxSize characters.
+func writeChat(w io.Writer, notes *ReleaseNotes, maxSize int) error {
+	r := newLinkRewriter(*owner, *repo, "CHANGELOG"+branchVerSuffix+".md", notePRs(notes))
+	lines := make([]string, 0)
+	add := func(format string, a ...interface{}) {
+		lines = append(lines, toMrkdwn(r, fmt.Sprintf(format, a...)))
+	}
+
+	add("**%s**", notes.Title)
+	add("[Documentation](%s) & [Examples](%s)", notes.DocURL, notes.ExampleURL)
+	if notes.Minor {
+		add("")
+		for _, l := range strings.Split(notes.Draft, "\n") {
+			add("%s", l)
+		}
+		if notes.Draft == "" {
+			for i, issue := range notes.KnownIssues {
+				if i == 0 {
+					add("**%s**", knownIssuesTitle)
+				}
+				add("• %s", strings.TrimPrefix(knownIssueItem(issue), "* "))
+			}
+			for i, e := range notes.Deprecations {
+				if i == 0 {
+					add("")
+					add("**%s**", deprecationsTitle)
+				}
+				add("• %s (#%d, @%s)", strings.Replace(e.Text, "\n", " ", -1), e.Number, e.Author)
+			}
+		}
+		for i, s := range notes.Enhancements {
+			if i == 0 {
+				add("")
+				add("**Major Themes by stage**")
+			}
+			add("**%s**", s.Title)
+			for _, e := range s.Enhancements {
+				add("• %s ([#%d](%s))", e.Title, e.Number, e.URL)
+				for _, n := range e.Entries {
+					add("    • %s (%s#%d, @%s)", strings.Replace(n.Text, "\n", " ", -1), n.Repo, n.Number, n.Author)
+				}
+			}
+		}
+	}
+	for i, r := range repoSummaries(notes) {
+		if i == 0 {
+			add("")
+		}
+		add("%s: %d release notes, %d action required, %d contributors", repoRange(r), r.Notes, r.ActionRequired, r.Contributors)
+	}
+	if len(notes.Repos) > 0 || !notes.Minor || hasEntries(notes.Sections) {
+		add("")
+		if len(notes.Repos) > 0 {
+			add("**Changelog**")
+		} else {
+			add("**Changelog since %s**", notes.StartTag)
+		}
+		if !hasEntries(notes.Sections) {
+			add("No notable changes for this release.")
+		}
+		var addSection func(s NoteSection, indent string)
+		addSection = func(s NoteSection, indent string) {
+			if !hasEntries([]NoteSection{s}) {
+				return
+			}
+			if indent == "" {
+				add("")
+			}
+			add("%s**%s**", indent, s.Title)
+			for _, e := range s.Entries {
+				add("%s• %s (%s#%d, @%s)", indent, strings.Replace(e.Text, "\n", " ", -1), e.Repo, e.Number, e.Author)
+			}
+			for _, sub := range s.Subsections {
+				addSection(sub, indent+"    ")
+			}
+		}
+		for _, s := range notes.Sections {
+			addSection(s, "")
+		}
+	}
+
+	messages := make([]chatMessage, 0)
+	for _, m := range splitMessages(lines, maxSize) {
+		messages = append(messages, chatMessage{m})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(messages)
+}
+
+// toMrkdwn links the references of input markdown line, and converts it to mrkdwn.
+func toMrkdwn(r *linkRewriter, line string) string {
+	line = mrkdwnEscaper.Replace(strings.TrimSuffix(r.rewrite(line), "\n"))
+	line = markdownLink.ReplaceAllString(line, "<$2|$1>")
+	return markdownBold.ReplaceAllString(line, "*$1*")
+}
+
+// splitMessages joins input lines into messages of at most maxSize characters, splitting between
+// lines when possible. Lines too long for a message are split on rune boundaries, so that the
+// messages stay valid UTF-8.
+func splitMessages(lines []string, maxSize int) []string {
+	messages := make([]string, 0)
+	var current string
+	// size is the length of current in characters
+	size := 0
+	for _, line := range lines {
+		runes := []rune(line)
+		for len(runes) > maxSize {
+			// Lines too long for a message are split on their own
+			if current != "" {
+				messages = append(messages, current)
+				current, size = "", 0
+			}
+			messages = append(messages, string(runes[:maxSize]))
+			runes = runes[maxSize:]
+		}
+		line = string(runes)
+		switch {
+		case current == "":
+			current, size = line, len(runes)
+		case size+1+len(runes) <= maxSize:
+			current += "\n" + line
+			size += 1 + len(runes)
+		default:
+			messages = append(messages, current)
+			current, size = line, len(runes)
+		}
+	}
+	if strings.TrimSpace(current) != "" {
+		messages = append(messages, current)
+	}
+	return messages
+}
+
+// notesHTML renders input release note as an HTML fragment, with linked references.
+func notesHTML(notes *ReleaseNotes) ([]byte, error) {
+	var md bytes.Buffer
+	if err := renderTemplate(&md, *templateFile, false, notes); err != nil {
+		return nil, err
+	}
+	r := newLinkRewriter(*owner, *repo, "CHANGELOG"+branchVerSuffix+".md", notePRs(notes))
+	return markdownToHTML([]byte(r.rewrite(md.String()))), nil
+}
+
+// writeEmail renders input release note as an RFC 5322 email, with plain text and HTML
+// alternative bodies.
+func writeEmail(w io.Writer, notes *ReleaseNotes, from, to string) error {
+	var text bytes.Buffer
+	if err := writeText(&text, notes); err != nil {
+		return err
+	}
+	html, err := notesHTML(notes)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	var b bytes.Buffer
+	b.WriteString(fmt.Sprintf("From: %s\r\n", from))
+	b.WriteString(fmt.Sprintf("To: %s\r\n", to))
+	// Non-ASCII titles are encoded as RFC 2047 words
+	b.WriteString(fmt.Sprintf("Subject: %s\r\n", mime.QEncoding.Encode("utf-8", notes.Title+" release notes")))
+	b.WriteString(fmt.Sprintf("Date: %s\r\n", notes.GeneratedAt.Format(time.RFC1123Z)))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString(fmt.Sprintf("Content-Type: multipart/alternative; boundary=%q\r\n\r\n", mw.Boundary()))
+
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=utf-8", text.Bytes()},
+		{"text/html; charset=utf-8", html},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err = qw.Write(part.content); err != nil {
+			return err
+		}
+		if err = qw.Close(); err != nil {
+			return err
+		}
+	}
+	if err = mw.Close(); err != nil {
+		return err
+	}
+
+	b.Write(body.Bytes())
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+// atomEntry is an Atom (RFC 4287) feed entry.
+type atomEntry struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2005/Atom entry"`
+	ID      string   `xml:"id"`
+	Title   string   `xml:"title"`
+	Updated string   `xml:"updated"`
+	Link    struct {
+		Rel  string `xml:"rel,attr"`
+		Href string `xml:"href,attr"`
+	} `xml:"link"`
+	Author struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Content struct {
+		Type string `xml:"type,attr"`
+		Body string `xml:",chardata"`
+	} `xml:"content"`
+}
+
+// atomLink returns the link of the Atom entry of input release note, which is also its ID: the
+// Github release of the release tag, or the comparison of the branch with the start tag for
+// unreleased notes, so that the entry keeps the same ID as the branch moves.
+func atomLink(notes *ReleaseNotes) string {
+	if unreleased(notes.Version) {
+		return fmt.Sprintf("https://github.com/%s/%s/compare/%s...%s", *owner, *repo, notes.StartTag, notes.Branch)
+	}
+	return fmt.Sprintf("https://github.com/%s/%s/releases/tag/%s", *owner, *repo, notes.Version)
+}
+
+// writeAtom renders input release note as an Atom entry, to be added to a release feed.
+func writeAtom(w io.Writer, notes *ReleaseNotes) error {
+	html, err := notesHTML(notes)
+	if err != nil {
+		return err
+	}
+
+	e := atomEntry{
+		Title:   notes.Title,
+		Updated: notes.GeneratedAt.UTC().Format(time.RFC3339),
+	}
+	e.Link.Rel = "alternate"
+	e.Link.Href = atomLink(notes)
+	e.ID = e.Link.Href
+	e.Author.Name = fmt.Sprintf("%s/%s", *owner, *repo)
+	e.Content.Type = "html"
+	e.Content.Body = string(html)
+
+	if _, err = io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err = enc.Encode(e); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
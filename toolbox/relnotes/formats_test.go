@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// newTestNotes creates the v1.8.1 release notes of newTestReleaseInfo for tests.
+func newTestNotes() *ReleaseNotes {
+	return &ReleaseNotes{
+		Title:       "v1.8.1",
+		Version:     "v1.8.1",
+		StartTag:    "v1.8.0",
+		DocURL:      "https://docs.k8s.io",
+		ExampleURL:  "https://releases.k8s.io/release-1.8/examples",
+		GeneratedAt: time.Date(2017, time.October, 3, 10, 0, 0, 0, time.UTC),
+		Sections:    patchRelease(newTestReleaseInfo()),
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	notes := newTestNotes()
+	notes.Sections[1].Entries[0].Text = "Fixes **pods**, see [#1](https://example.com) and `kubectl`."
+
+	want := "v1.8.1\n======\n\n" +
+		"Documentation: https://docs.k8s.io\nExamples: https://releases.k8s.io/release-1.8/examples\n\n" +
+		"Changelog since v1.8.0\n----------------------\n\n" +
+		"Action Required:\n- The --foo flag was removed. (#52602, @thockin)\n\n" +
+		"Other notable changes:\n- Fixes pods, see #1 and kubectl. (#53233, @liggitt)\n" +
+		"- Change default --cert-dir for kubelet (#53317, @liggitt)\n\n"
+
+	var b bytes.Buffer
+	if err := writeText(&b, notes); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if b.String() != want {
+		t.Errorf("Text notes were incorrect, want:\n%s\ngot:\n%s", want, b.String())
+	}
+}
+
+func TestWriteChat(t *testing.T) {
+	notes := newTestNotes()
+	notes.Sections[1].Entries[0].Text = "Fixes **pods** for a < b & c"
+
+	var b bytes.Buffer
+	if err := writeChat(&b, notes, 4000); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var messages []chatMessage
+	if err := json.Unmarshal(b.Bytes(), &messages); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("Number of messages was incorrect, want: %v, got: %v", 1, len(messages))
+	}
+	for _, s := range []string{
+		"*v1.8.1*\n<https://docs.k8s.io|Documentation> &amp; <https://releases.k8s.io/release-1.8/examples|Examples>\n",
+		"\n\n*Action Required*\n",
+		"• Fixes *pods* for a &lt; b &amp; c (<https://github.com/kubernetes/kubernetes/pull/53233|#53233>, <https://github.com/liggitt|@liggitt>)",
+	} {
+		if !strings.Contains(messages[0].Text, s) {
+			t.Errorf("Chat message missing %q:\n%s", s, messages[0].Text)
+		}
+	}
+
+	// Every message fits in the size limit
+	b.Reset()
+	if err := writeChat(&b, notes, 100); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := json.Unmarshal(b.Bytes(), &messages); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(messages) < 2 {
+		t.Errorf("Expected notes to be split, got %d message(s)", len(messages))
+	}
+	for _, m := range messages {
+		if utf8.RuneCountInString(m.Text) > 100 {
+			t.Errorf("Message is longer than 100 characters: %q", m.Text)
+		}
+	}
+}
+
+func TestSplitMessages(t *testing.T) {
+	tables := []struct {
+		lines   []string
+		maxSize int
+		want    []string
+	}{
+		{[]string{"a", "b", "c"}, 10, []string{"a\nb\nc"}},
+		{[]string{"aaaa", "bbbb", "cc"}, 9, []string{"aaaa\nbbbb", "cc"}},
+		{[]string{"aa", "bbbbbbb", "c"}, 3, []string{"aa", "bbb", "bbb", "b\nc"}},
+		{[]string{"a", "", ""}, 3, []string{"a\n\n"}},
+		{[]string{"", ""}, 3, []string{}},
+		// Sizes are in characters, "é" and "日" are 2 and 3 bytes long
+		{[]string{"aébc"}, 2, []string{"aé", "bc"}},
+		{[]string{"日本語"}, 2, []string{"日本", "語"}},
+		{[]string{"é", "日"}, 3, []string{"é\n日"}},
+		{[]string{"日本", "語"}, 3, []string{"日本", "語"}},
+	}
+
+	for _, table := range tables {
+		got := splitMessages(table.lines, table.maxSize)
+		if !reflect.DeepEqual(got, table.want) {
+			t.Errorf("%q: Messages were incorrect, want: %q, got: %q", table.lines, table.want, got)
+		}
+		for _, m := range got {
+			if !utf8.ValidString(m) {
+				t.Errorf("%q: Message %q isn't valid UTF-8", table.lines, m)
+			}
+		}
+	}
+}
+
+func TestWriteEmail(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeEmail(&b, newTestNotes(), "release@k8s.io", "dev@kubernetes.io"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	msg, err := mail.ReadMessage(&b)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	headers := map[string]string{
+		"From":         "release@k8s.io",
+		"To":           "dev@kubernetes.io",
+		"Subject":      "v1.8.1 release notes",
+		"Date":         "Tue, 03 Oct 2017 10:00:00 +0000",
+		"Mime-Version": "1.0",
+	}
+	for k, v := range headers {
+		if got := msg.Header.Get(k); got != v {
+			t.Errorf("%s header was incorrect, want: %v, got: %v", k, v, got)
+		}
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("Content type was incorrect, want: %v, got: %v", "multipart/alternative", mediaType)
+	}
+
+	parts := map[string]string{}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextRawPart()
+		if err != nil {
+			break
+		}
+		content, err := ioutil.ReadAll(quotedprintable.NewReader(p))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		// Line breaks are CRLF in emails
+		parts[p.Header.Get("Content-Type")] = strings.Replace(string(content), "\r\n", "\n", -1)
+	}
+
+	tables := []struct {
+		contentType string
+		want        string
+	}{
+		{"text/plain; charset=utf-8", "v1.8.1\n======\n"},
+		{"text/plain; charset=utf-8", "- The --foo flag was removed. (#52602, @thockin)\n"},
+		{"text/html; charset=utf-8", "<h1 id=\"v1-8-1\">v1.8.1</h1>"},
+		{"text/html; charset=utf-8", "(<a href=\"https://github.com/kubernetes/kubernetes/pull/52602\">#52602</a>"},
+	}
+	for _, table := range tables {
+		if !strings.Contains(parts[table.contentType], table.want) {
+			t.Errorf("%s part missing %q:\n%s", table.contentType, table.want, parts[table.contentType])
+		}
+	}
+}
+
+func TestWriteEmailSubject(t *testing.T) {
+	tables := []struct {
+		title string
+		want  string
+	}{
+		{"v1.8.1", "v1.8.1 release notes"},
+		{"v1.8.1 – Überraschung", "=?utf-8?q?v1.8.1_=E2=80=93_=C3=9Cberraschung_release_notes?="},
+	}
+
+	for _, table := range tables {
+		notes := newTestNotes()
+		notes.Title = table.title
+		var b bytes.Buffer
+		if err := writeEmail(&b, notes, "release@k8s.io", "dev@kubernetes.io"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		msg, err := mail.ReadMessage(&b)
+		if err != nil {
+			t.Fatalf("%s: Unexpected error: %v", table.title, err)
+		}
+		subject := msg.Header.Get("Subject")
+		if subject != table.want {
+			t.Errorf("%s: Subject was incorrect, want: %v, got: %v", table.title, table.want, subject)
+		}
+		if decoded, err := new(mime.WordDecoder).DecodeHeader(subject); err != nil || decoded != table.title+" release notes" {
+			t.Errorf("%s: Decoded subject was incorrect, got: %v, %v", table.title, decoded, err)
+		}
+	}
+}
+
+func TestWriteAtom(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeAtom(&b, newTestNotes()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var e atomEntry
+	if err := xml.Unmarshal(b.Bytes(), &e); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	url := "https://github.com/kubernetes/kubernetes/releases/tag/v1.8.1"
+	tables := []struct {
+		field string
+		want  string
+		got   string
+	}{
+		{"id", url, e.ID},
+		{"title", "v1.8.1", e.Title},
+		{"updated", "2017-10-03T10:00:00Z", e.Updated},
+		{"link", url, e.Link.Href},
+		{"content type", "html", e.Content.Type},
+	}
+	for _, table := range tables {
+		if table.got != table.want {
+			t.Errorf("Atom %s was incorrect, want: %v, got: %v", table.field, table.want, table.got)
+		}
+	}
+	if !strings.Contains(e.Content.Body, "<h3 id=\"action-required\">Action Required</h3>") {
+		t.Errorf("Atom content missing the Action Required section:\n%s", e.Content.Body)
+	}
+
+	// Unreleased notes keep the same ID as the branch head moves
+	for _, head := range []string{"5adaee21de0c5ed1286a00468e09d866605f85f4", "0123456789abcdef0123456789abcdef01234567"} {
+		branchHead = head
+		notes := newTestNotes()
+		notes.Version = head
+		notes.Branch = "release-1.8"
+		b.Reset()
+		if err := writeAtom(&b, notes); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := xml.Unmarshal(b.Bytes(), &e); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if want := "https://github.com/kubernetes/kubernetes/compare/v1.8.0...release-1.8"; e.ID != want {
+			t.Errorf("Atom id of unreleased notes was incorrect, want: %v, got: %v", want, e.ID)
+		}
+	}
+	branchHead = ""
+}
@@ -0,0 +1,108 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/russross/blackfriday"
+)
+
+const (
+	// defaultCSS is the stylesheet embedded in HTML release notes, unless --html-css is given.
+	defaultCSS = "table,th,tr,td {border: 1px solid gray; border-collapse: collapse;padding: 5px;}"
+
+	// markdownExtensions are the Github flavored markdown extensions used to render release notes.
+	markdownExtensions = blackfriday.EXTENSION_NO_INTRA_EMPHASIS |
+		blackfriday.EXTENSION_TABLES |
+		blackfriday.EXTENSION_FENCED_CODE |
+		blackfriday.EXTENSION_AUTOLINK |
+		blackfriday.EXTENSION_STRIKETHROUGH |
+		blackfriday.EXTENSION_SPACE_HEADERS |
+		blackfriday.EXTENSION_AUTO_HEADER_IDS
+)
+
+// renderHTML converts input markdown into a standalone HTML document, with input stylesheet
+// embedded.
+func renderHTML(w io.Writer, markdown []byte, title, css string) error {
+	body := markdownToHTML(markdown)
+
+	var b bytes.Buffer
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	b.WriteString("<meta charset=\"utf-8\">\n")
+	b.WriteString(fmt.Sprintf("<title>%s</title>\n", html.EscapeString(title)))
+	b.WriteString(fmt.Sprintf("<style type=\"text/css\">\n%s\n</style>\n", css))
+	b.WriteString("</head>\n<body>\n")
+	b.Write(body)
+	b.WriteString("</body>\n</html>\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// markdownToHTML converts input markdown into an HTML fragment.
+func markdownToHTML(markdown []byte) []byte {
+	renderer := blackfriday.HtmlRenderer(0, "", "")
+	return blackfriday.Markdown(markdown, renderer, markdownExtensions)
+}
+
+// createHTMLNote generates HTML release note based on the input markdown release note. The
+// stylesheet is read from cssFileName if given.
+func createHTMLNote(htmlFileName, mdFileName, cssFileName string) error {
+	var result error
+	log.Print("Generating HTML release note...")
+
+	markdown, err := ioutil.ReadFile(mdFileName)
+	if err != nil {
+		return fmt.Errorf("failed to read markdown file %s: %v", mdFileName, err)
+	}
+	css, err := readCSS(cssFileName)
+	if err != nil {
+		return err
+	}
+
+	htmlFile, err := os.Create(htmlFileName)
+	if err != nil {
+		return fmt.Errorf("failed to create html file: %v", err)
+	}
+	defer func() {
+		if err = htmlFile.Close(); err != nil {
+			result = fmt.Errorf("failed to close file %s, %v", htmlFileName, err)
+		}
+	}()
+
+	if err = renderHTML(htmlFile, markdown, "Release Notes", css); err != nil {
+		return fmt.Errorf("failed to write html file %s: %v", htmlFileName, err)
+	}
+	return result
+}
+
+// readCSS reads the stylesheet of HTML documents from input file, or returns defaultCSS if
+// cssFileName is empty.
+func readCSS(cssFileName string) (string, error) {
+	if cssFileName == "" {
+		return defaultCSS, nil
+	}
+	content, err := ioutil.ReadFile(cssFileName)
+	if err != nil {
+		return "", fmt.Errorf("failed to read css file %s: %v", cssFileName, err)
+	}
+	return string(content), nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderHTML(t *testing.T) {
+	markdown := "# v1.8.1\n\n" +
+		"filename | sha256 hash\n-------- | -----------\n[kubernetes.tar.gz](https://dl.k8s.io/v1.8.1/kubernetes.tar.gz) | `abc`\n\n" +
+		"### Other notable changes\n\n* Fixes pods. ([#53233](https://github.com/kubernetes/kubernetes/pull/53233), [@liggitt](https://github.com/liggitt))\n"
+
+	var b bytes.Buffer
+	if err := renderHTML(&b, []byte(markdown), "Release <Notes>", defaultCSS); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, s := range []string{
+		"<title>Release &lt;Notes&gt;</title>",
+		"<style type=\"text/css\">\n" + defaultCSS + "\n</style>",
+		"<h1 id=\"v1-8-1\">v1.8.1</h1>",
+		"<table>",
+		"<td><a href=\"https://dl.k8s.io/v1.8.1/kubernetes.tar.gz\">kubernetes.tar.gz</a></td>",
+		"<td><code>abc</code></td>",
+		"<li>Fixes pods. (<a href=\"https://github.com/kubernetes/kubernetes/pull/53233\">#53233</a>",
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("HTML missing %q:\n%s", s, b.String())
+		}
+	}
+}
+
+func TestCreateHTMLNoteCSS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "relnotes-html")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	mdFile := filepath.Join(dir, "notes.md")
+	cssFile := filepath.Join(dir, "notes.css")
+	htmlFile := filepath.Join(dir, "notes.html")
+	ioutil.WriteFile(mdFile, []byte("# v1.8.1\n"), 0644)
+	ioutil.WriteFile(cssFile, []byte("body {color: navy;}"), 0644)
+
+	tables := []struct {
+		css  string
+		want string
+	}{
+		{"", defaultCSS},
+		{cssFile, "body {color: navy;}"},
+	}
+	for _, table := range tables {
+		if err := createHTMLNote(htmlFile, mdFile, table.css); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		content, err := ioutil.ReadFile(htmlFile)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(string(content), table.want) {
+			t.Errorf("%q: HTML missing stylesheet %q:\n%s", table.css, table.want, content)
+		}
+	}
+
+	if err := createHTMLNote(htmlFile, mdFile, filepath.Join(dir, "missing.css")); err == nil {
+		t.Errorf("Expected error for missing css file")
+	}
+}
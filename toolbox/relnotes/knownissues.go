@@ -0,0 +1,258 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/github"
+	u "k8s.io/release/toolbox/util"
+)
+
+const (
+	knownIssuesTitle  = "Known Issues"
+	deprecationsTitle = "Deprecations"
+	deprecationLabel  = "kind/deprecation"
+)
+
+var (
+	// releaseMinor matches the vX.Y prefix of release versions, e.g. "v1.8" in "v1.8.0-rc.1".
+	releaseMinor = regexp.MustCompile("^v[0-9]+\\.[0-9]+")
+	// draftHeading matches the markdown headings of a release notes draft, e.g. "## Known Issues".
+	draftHeading = regexp.MustCompile("^(#+)\\s+(.*?)\\s*#*\\s*$")
+	// placeholderItem matches the "* TBD" placeholder of an empty draft section.
+	placeholderItem = regexp.MustCompile("^[*-]\\s+TBD\\s*$")
+)
+
+// KnownIssue is an open issue listed in the Known Issues section of a release.
+type KnownIssue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+}
+
+// listKnownIssues lists the open issues of the repo with given label and milestone.
+func listKnownIssues(g *u.GithubClient, owner, repo, label, milestone string) ([]KnownIssue, error) {
+	log.Printf("Getting open %s issues of milestone %s...", label, milestone)
+
+	var query []string
+	query = u.AddQuery(query, "repo", owner, "/", repo)
+	query = u.AddQuery(query, "is", "open")
+	query = u.AddQuery(query, "type", "issue")
+	query = u.AddQuery(query, "label", "\"", label, "\"")
+	query = u.AddQuery(query, "milestone", "\"", milestone, "\"")
+	issues, err := g.SearchIssues(strings.Join(query, " "))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search known issues: %v", err)
+	}
+
+	known := make([]KnownIssue, 0, len(issues))
+	for _, i := range issues {
+		known = append(known, KnownIssue{*i.Number, *i.Title, *i.HTMLURL})
+	}
+	return known, nil
+}
+
+// issuesMilestoneOf returns the milestone of the known issues of input release, e.g. "v1.8" for
+// "v1.8.0".
+func issuesMilestoneOf(release string) (string, error) {
+	m := releaseMinor.FindString(release)
+	if m == "" {
+		return "", fmt.Errorf("can't determine the milestone of release %s", release)
+	}
+	return m, nil
+}
+
+// releaseMilestone returns the milestone of the known issues and enhancements of input release:
+// --known-issues-milestone if set, or the vX.Y of the release.
+func releaseMilestone(info *ReleaseInfo) (string, error) {
+	if *issuesMilestone != "" {
+		return *issuesMilestone, nil
+	}
+	return issuesMilestoneOf(info.releaseTag)
+}
+
+// listDeprecations lists the merged kind/deprecation PRs of the repo.
+func listDeprecations(g *u.GithubClient, owner, repo string) ([]github.Issue, error) {
+	log.Printf("Getting merged %s PRs...", deprecationLabel)
+
+	var query []string
+	query = u.AddQuery(query, "repo", owner, "/", repo)
+	query = u.AddQuery(query, "is", "merged")
+	query = u.AddQuery(query, "type", "pr")
+	query = u.AddQuery(query, "label", "\"", deprecationLabel, "\"")
+	prs, err := g.SearchIssues(strings.Join(query, " "))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search deprecations: %v", err)
+	}
+	return prs, nil
+}
+
+// deprecationEntries returns the release notes of the input kind/deprecation PRs which are in the
+// range of a release, action required ones first. PRs without release-note label are listed too,
+// with the note of their body or their title.
+func deprecationEntries(info *ReleaseInfo, deprecated []github.Issue) []NoteEntry {
+	byNumber := make(map[int]*github.Issue)
+	for i := range deprecated {
+		byNumber[*deprecated[i].Number] = &deprecated[i]
+	}
+
+	entries := make([]NoteEntry, 0)
+	listed := make(map[int]bool)
+	for _, pr := range info.releaseActionRequiredPRs {
+		if byNumber[pr] != nil {
+			entries = append(entries, newNoteEntry(info.prMap[pr], true))
+			listed[pr] = true
+		}
+	}
+	for _, pr := range info.rangePRs {
+		if byNumber[pr] == nil || listed[pr] {
+			continue
+		}
+		entries = append(entries, newNoteEntry(byNumber[pr], false))
+		listed[pr] = true
+	}
+	return entries
+}
+
+// knownIssueItem renders a known issue as a markdown list item, like the "knownIssue" template.
+func knownIssueItem(i KnownIssue) string {
+	return fmt.Sprintf("* %s ([#%d](%s))", i.Title, i.Number, i.URL)
+}
+
+// deprecationItem renders a deprecation as a markdown list item, like the "entry" template.
+func deprecationItem(e NoteEntry) string {
+	return fmt.Sprintf("* %s (#%d, @%s)", indent(2, e.Text), e.Number, e.Author)
+}
+
+// mergeDraftSection adds input markdown list items to the section of a release notes draft with
+// given title, after its hand-written items and in place of a "* TBD" placeholder. The section
+// is appended to the draft if it doesn't have one.
+func mergeDraftSection(draft, title string, items []string) string {
+	if len(items) == 0 {
+		return draft
+	}
+	lines := strings.Split(strings.TrimRight(draft, "\n"), "\n")
+
+	start, level := -1, 0
+	fence := ""
+	for i, l := range lines {
+		if m := markdownFence.FindStringSubmatch(l); m != nil {
+			if fence == "" {
+				fence = m[1]
+			} else if m[1][0] == fence[0] && len(m[1]) >= len(fence) {
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+		m := draftHeading.FindStringSubmatch(l)
+		if m == nil {
+			continue
+		}
+		if start == -1 && strings.EqualFold(m[2], title) {
+			start, level = i, len(m[1])
+			continue
+		}
+		if start != -1 && len(m[1]) <= level {
+			return mergeLines(lines[:start+1], lines[start+1:i], items, lines[i:])
+		}
+	}
+	if start == -1 {
+		if draft == "" {
+			return fmt.Sprintf("## %s\n\n%s\n", title, strings.Join(items, "\n"))
+		}
+		return fmt.Sprintf("%s\n\n## %s\n\n%s\n", strings.TrimRight(draft, "\n"), title, strings.Join(items, "\n"))
+	}
+	return mergeLines(lines[:start+1], lines[start+1:], items, nil)
+}
+
+// mergeLines joins the heading, the body without placeholder and the new items of a draft section
+// with the rest of the draft.
+func mergeLines(heading, body, items, rest []string) string {
+	kept := make([]string, 0, len(body))
+	for _, l := range body {
+		if !placeholderItem.MatchString(l) {
+			kept = append(kept, l)
+		}
+	}
+	section := strings.Trim(strings.Join(kept, "\n"), "\n")
+	if section != "" {
+		section += "\n"
+	}
+	section += strings.Join(items, "\n")
+
+	merged := strings.Join(heading, "\n") + "\n\n" + section + "\n"
+	if len(rest) > 0 {
+		merged += "\n" + strings.Join(rest, "\n") + "\n"
+	}
+	return merged
+}
+
+// gatherKnownIssues fills the known issues and deprecations of a minor release, and merges the
+// ones the release notes draft doesn't reference yet into the draft sections. The deprecations are
+// the ones of the PRs of info, so the range of a vX.Y.0 release must cover the whole minor
+// release.
+func gatherKnownIssues(g *u.GithubClient, info *ReleaseInfo, notes *ReleaseNotes) error {
+	if err := checkMinorRange(info); err != nil {
+		return fmt.Errorf("failed to gather deprecations: %v", err)
+	}
+	milestone, err := releaseMilestone(info)
+	if err != nil {
+		return err
+	}
+	notes.KnownIssues, err = listKnownIssues(g, *owner, *repo, *issuesLabel, milestone)
+	if err != nil {
+		return err
+	}
+	deprecated, err := listDeprecations(g, *owner, *repo)
+	if err != nil {
+		return err
+	}
+	notes.Deprecations = deprecationEntries(info, deprecated)
+	log.Printf("%d known issues, %d deprecations", len(notes.KnownIssues), len(notes.Deprecations))
+
+	if notes.Draft != "" {
+		notes.Draft = mergeKnownIssues(notes.Draft, notes.KnownIssues, notes.Deprecations)
+	}
+	return nil
+}
+
+// mergeKnownIssues merges the known issues and deprecations which input draft doesn't reference
+// into its Known Issues and Deprecations sections.
+func mergeKnownIssues(draft string, issues []KnownIssue, deprecations []NoteEntry) string {
+	referenced := draftPRs(draft)
+	issueItems := make([]string, 0)
+	for _, i := range issues {
+		// Issues are referenced as #123 or by their URL
+		byURL := regexp.MustCompile(regexp.QuoteMeta(i.URL) + "\\b")
+		if !referenced[i.Number] && !byURL.MatchString(draft) {
+			issueItems = append(issueItems, knownIssueItem(i))
+		}
+	}
+	deprecationItems := make([]string, 0)
+	for _, e := range deprecations {
+		if !referenced[e.Number] {
+			deprecationItems = append(deprecationItems, deprecationItem(e))
+		}
+	}
+	draft = mergeDraftSection(draft, knownIssuesTitle, issueItems)
+	return mergeDraftSection(draft, deprecationsTitle, deprecationItems)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/github"
+)
+
+func TestIssuesMilestoneOf(t *testing.T) {
+	tables := []struct {
+		release string
+		want    string
+		err     bool
+	}{
+		{"v1.8.0", "v1.8", false},
+		{"v1.10.0-rc.1", "v1.10", false},
+		{"HEAD", "", true},
+	}
+
+	for _, table := range tables {
+		got, err := issuesMilestoneOf(table.release)
+		if (err != nil) != table.err || got != table.want {
+			t.Errorf("%v: Milestone was incorrect, want: %v (error: %v), got: %v (%v)", table.release, table.want, table.err, got, err)
+		}
+	}
+}
+
+func TestReleaseMilestone(t *testing.T) {
+	info := &ReleaseInfo{startTag: "v1.7.0", releaseTag: "v1.8.0"}
+	if got, err := releaseMilestone(info); err != nil || got != "v1.8" {
+		t.Errorf("Milestone was incorrect, want: v1.8, got: %v (%v)", got, err)
+	}
+
+	*issuesMilestone = "v1.8-next"
+	defer func() { *issuesMilestone = "" }()
+	if got, err := releaseMilestone(info); err != nil || got != "v1.8-next" {
+		t.Errorf("Milestone was incorrect, want: v1.8-next, got: %v (%v)", got, err)
+	}
+}
+
+func TestDeprecationEntries(t *testing.T) {
+	info := newTestReleaseInfo()
+	info.prMap[52602].Labels[1].Name = github.String(deprecationLabel)
+	// 53400 has no release-note label, so it isn't in prMap, and 51000 isn't in range
+	info.rangePRs = []int{53233, 53400, 52602, 53317}
+	deprecated := []github.Issue{
+		*newTestPR(51000, "thockin", "Deprecate the bar flag", "", deprecationLabel),
+		*info.prMap[52602],
+		*newTestPR(53400, "deads2k", "Deprecate the baz API", "```release-note\r\nNONE\r\n```", deprecationLabel),
+	}
+
+	entries := deprecationEntries(info, deprecated)
+	want := []NoteEntry{
+		{Number: 52602, Author: "thockin", Text: "The --foo flag was removed.", ActionRequired: true},
+		{Number: 53400, Author: "deads2k", Text: "Deprecate the baz API"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("Deprecations were incorrect, want: %+v, got: %+v", want, entries)
+	}
+	for i, e := range entries {
+		if e.Number != want[i].Number || e.Author != want[i].Author || e.Text != want[i].Text || e.ActionRequired != want[i].ActionRequired {
+			t.Errorf("Deprecation was incorrect, want: %+v, got: %+v", want[i], e)
+		}
+	}
+}
+
+func TestMergeDraftSection(t *testing.T) {
+	items := []string{"* Pods are slow ([#1](https://github.com/kubernetes/kubernetes/issues/1))"}
+	tables := []struct {
+		draft string
+		want  string
+	}{
+		// Placeholder replaced, following sections kept
+		{
+			"## Major Themes\n\n* TBD\n\n## Known Issues\n\n* TBD\n\n## Provider-specific Notes\n\n* TBD\n",
+			"## Major Themes\n\n* TBD\n\n## Known Issues\n\n" + items[0] + "\n\n## Provider-specific Notes\n\n* TBD\n",
+		},
+		// Hand-written entries kept first, subsections are part of the section
+		{
+			"## Known Issues\n\nIntro.\n\n* Nodes are slow (#2)\n\n### Node\n\n* Details\n\n# Next\n",
+			"## Known Issues\n\nIntro.\n\n* Nodes are slow (#2)\n\n### Node\n\n* Details\n" + items[0] + "\n\n# Next\n",
+		},
+		// Last section of the draft, headings in code blocks ignored
+		{
+			"# v1.8.0\n\n## known issues\n\n```\n# Not a heading\n```\n",
+			"# v1.8.0\n\n## known issues\n\n```\n# Not a heading\n```\n" + items[0] + "\n",
+		},
+		// Missing section
+		{
+			"## Major Themes\n\n* Workloads API goes beta\n",
+			"## Major Themes\n\n* Workloads API goes beta\n\n## Known Issues\n\n" + items[0] + "\n",
+		},
+	}
+
+	for _, table := range tables {
+		if got := mergeDraftSection(table.draft, knownIssuesTitle, items); got != table.want {
+			t.Errorf("%q: Draft was incorrect, want:\n%s\ngot:\n%s", table.draft, table.want, got)
+		}
+	}
+	if got := mergeDraftSection(tables[0].draft, knownIssuesTitle, nil); got != tables[0].draft {
+		t.Errorf("Expected draft to be unchanged without items, got:\n%s", got)
+	}
+}
+
+func TestMergeKnownIssues(t *testing.T) {
+	draft := "## Known Issues\n\n* Hand-written, see https://github.com/kubernetes/kubernetes/issues/1\n\n" +
+		"## Deprecations\n\n* Already documented (#53233)\n"
+	issues := []KnownIssue{
+		{1, "Pods are slow", "https://github.com/kubernetes/kubernetes/issues/1"},
+		{2, "Nodes are slow", "https://github.com/kubernetes/kubernetes/issues/2"},
+	}
+	deprecations := []NoteEntry{
+		{Number: 53233, Author: "liggitt", Text: "Deprecates pods."},
+		{Number: 53317, Author: "liggitt", Text: "Deprecates --cert-dir."},
+	}
+
+	want := "## Known Issues\n\n* Hand-written, see https://github.com/kubernetes/kubernetes/issues/1\n" +
+		"* Nodes are slow ([#2](https://github.com/kubernetes/kubernetes/issues/2))\n\n" +
+		"## Deprecations\n\n* Already documented (#53233)\n* Deprecates --cert-dir. (#53317, @liggitt)\n"
+	if got := mergeKnownIssues(draft, issues, deprecations); got != want {
+		t.Errorf("Draft was incorrect, want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestWriteMarkdownKnownIssues(t *testing.T) {
+	notes := &ReleaseNotes{
+		Title:        "v1.8.0",
+		Version:      "v1.8.0",
+		Minor:        true,
+		KnownIssues:  []KnownIssue{{2, "Nodes are slow", "https://github.com/kubernetes/kubernetes/issues/2"}},
+		Deprecations: []NoteEntry{{Number: 53317, Author: "liggitt", Text: "Deprecates --cert-dir."}},
+	}
+
+	var b bytes.Buffer
+	if err := writeMarkdown(&b, notes); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := "## Known Issues\n\n* Nodes are slow ([#2](https://github.com/kubernetes/kubernetes/issues/2))\n\n" +
+		"## Deprecations\n\n* Deprecates --cert-dir. (#53317, @liggitt)\n\n" +
+		"## Provider-specific Notes\n"
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("Markdown missing %q:\n%s", want, b.String())
+	}
+
+	b.Reset()
+	if err := writeText(&b, notes); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want = "Known Issues\n------------\n\n- Nodes are slow (#2)\n  https://github.com/kubernetes/kubernetes/issues/2\n\n" +
+		"Deprecations:\n- Deprecates --cert-dir. (#53317, @liggitt)\n\n"
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("Text missing %q:\n%s", want, b.String())
+	}
+}
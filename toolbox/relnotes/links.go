@@ -0,0 +1,174 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// protectedMarkdown matches the inline markdown which must not be rewritten: code spans,
+	// links (whose target is handled separately), HTML tags and bare URLs.
+	protectedMarkdown = regexp.MustCompile("(`+)[^`]*?(`+)|\\[[^\\]]*\\]\\(([^)]*)\\)|<[^>]+>|https?://[^\\s)]+")
+	// reference matches, in order: cross-repository issues (owner/repo#123), issues and PRs
+	// (#123), user mentions (@user) and commit SHAs. The leading group makes sure references
+	// aren't part of a word, e.g. in an email address.
+	reference = regexp.MustCompile("(^|[^\\w/@#&-])(?:([\\w.-]+/[\\w.-]+)#([0-9]+)|#([0-9]+)|@([a-zA-Z0-9][a-zA-Z0-9-]*)|([0-9a-f]{7,40}))\\b")
+	// markdownFence matches the opening or closing line of a fenced code block.
+	markdownFence = regexp.MustCompile("^\\s*(`{3,}|~{3,})")
+)
+
+// linkRewriter rewrites the PR, issue, user, commit and CHANGELOG anchor references of markdown
+// release notes into links, for the repository they were gathered from.
+type linkRewriter struct {
+	githubURL string
+	repoURL   string
+	// changelogURL is the URL of the CHANGELOG file, which "#v1.8.1"-style anchors refer to
+	changelogURL string
+	// prs contains the numbers known to be PRs. Other numbers are linked as issues (Github
+	// redirects issue links to PRs, but not the other way around).
+	prs map[int]bool
+}
+
+// newLinkRewriter creates a link rewriter for input repository, CHANGELOG file and known PRs.
+func newLinkRewriter(owner, repo, changelog string, prs []int) *linkRewriter {
+	r := &linkRewriter{
+		githubURL: "https://github.com",
+		prs:       make(map[int]bool),
+	}
+	r.repoURL = fmt.Sprintf("%s/%s/%s", r.githubURL, owner, repo)
+	r.changelogURL = fmt.Sprintf("%s/blob/master/%s", r.repoURL, changelog)
+	for _, pr := range prs {
+		r.prs[pr] = true
+	}
+	return r
+}
+
+// notePRs returns the numbers of all the PRs in input release notes.
+func notePRs(notes *ReleaseNotes) []int {
+	prs := make([]int, 0)
+	var add func(sections []NoteSection)
+	add = func(sections []NoteSection) {
+		for _, s := range sections {
+			for _, e := range s.Entries {
+				// PRs of other repositories are linked by their owner/repo#123 reference
+				if e.Repo == "" {
+					prs = append(prs, e.Number)
+				}
+			}
+			add(s.Subsections)
+		}
+	}
+	add(notes.Sections)
+	for _, pr := range notes.PendingPRs {
+		prs = append(prs, pr.Number)
+	}
+	return prs
+}
+
+// rewrite rewrites the references of input markdown into links. Fenced code blocks, code spans,
+// existing links, HTML tags and URLs are left untouched, except for CHANGELOG anchor link
+// targets which are made absolute.
+func (r *linkRewriter) rewrite(markdown string) string {
+	var b bytes.Buffer
+	fence := ""
+	lines := strings.SplitAfter(markdown, "\n")
+	for _, line := range lines {
+		if m := markdownFence.FindStringSubmatch(line); m != nil {
+			if fence == "" {
+				fence = m[1]
+			} else if m[1][0] == fence[0] && len(m[1]) >= len(fence) {
+				fence = ""
+			}
+			b.WriteString(line)
+			continue
+		}
+		if fence != "" {
+			b.WriteString(line)
+			continue
+		}
+		b.WriteString(r.rewriteLine(line))
+	}
+	return b.String()
+}
+
+// rewriteLine rewrites the references of a line which isn't in a fenced code block.
+func (r *linkRewriter) rewriteLine(line string) string {
+	var b bytes.Buffer
+	last := 0
+	for _, loc := range protectedMarkdown.FindAllStringSubmatchIndex(line, -1) {
+		b.WriteString(r.rewriteText(line[last:loc[0]]))
+		token := line[loc[0]:loc[1]]
+		if loc[6] != -1 && strings.HasPrefix(line[loc[6]:loc[7]], "#v") {
+			// Link to a CHANGELOG anchor
+			token = line[loc[0]:loc[6]] + r.changelogURL + line[loc[6]:loc[1]]
+		}
+		b.WriteString(token)
+		last = loc[1]
+	}
+	b.WriteString(r.rewriteText(line[last:]))
+	return b.String()
+}
+
+// rewriteText rewrites the references of plain markdown text.
+func (r *linkRewriter) rewriteText(text string) string {
+	var b bytes.Buffer
+	last := 0
+	for _, loc := range reference.FindAllStringSubmatchIndex(text, -1) {
+		group := func(i int) string {
+			if loc[2*i] == -1 {
+				return ""
+			}
+			return text[loc[2*i]:loc[2*i+1]]
+		}
+		start, ref := loc[3], text[loc[3]:loc[1]]
+
+		var link string
+		switch {
+		case group(2) != "":
+			link = fmt.Sprintf("%s/%s/issues/%s", r.githubURL, group(2), group(3))
+		case group(4) != "":
+			number, _ := strconv.Atoi(group(4))
+			kind := "issues"
+			if r.prs[number] {
+				kind = "pull"
+			}
+			link = fmt.Sprintf("%s/%s/%s", r.repoURL, kind, group(4))
+		case group(5) != "" && !strings.HasPrefix(text[loc[1]:], "/"):
+			// Team mentions (@org/team) are left as is
+			link = fmt.Sprintf("%s/%s", r.githubURL, group(5))
+		case isCommitSHA(group(6)):
+			link = fmt.Sprintf("%s/commit/%s", r.repoURL, group(6))
+		}
+		if link == "" {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(fmt.Sprintf("[%s](%s)", ref, link))
+		last = loc[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// isCommitSHA guesses if input hexadecimal word is a commit SHA rather than a number or a word,
+// by requiring both digits and letters.
+func isCommitSHA(s string) bool {
+	return strings.ContainsAny(s, "0123456789") && strings.ContainsAny(s, "abcdef")
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRewriteLinks(t *testing.T) {
+	r := newLinkRewriter("kubernetes", "kubernetes", "CHANGELOG-1.8.md", []int{53233})
+	pr := "https://github.com/kubernetes/kubernetes/pull/"
+	issue := "https://github.com/kubernetes/kubernetes/issues/"
+
+	tables := []struct {
+		markdown string
+		want     string
+	}{
+		{"* Fixes pods. (#53233, @liggitt)", "* Fixes pods. ([#53233](" + pr + "53233), [@liggitt](https://github.com/liggitt))"},
+		{"Fixes #51899 and #7", "Fixes [#51899](" + issue + "51899) and [#7](" + issue + "7)"},
+		{"See kubernetes/test-infra#4321", "See [kubernetes/test-infra#4321](https://github.com/kubernetes/test-infra/issues/4321)"},
+		{"Reverts 5adaee21de0c and deadbeef", "Reverts [5adaee21de0c](https://github.com/kubernetes/kubernetes/commit/5adaee21de0c) and deadbeef"},
+		{"Contact foo@example.com or @kubernetes/sig-node-bugs", "Contact foo@example.com or @kubernetes/sig-node-bugs"},
+		{"Use `kubectl get #123 @me` now", "Use `kubectl get #123 @me` now"},
+		{"See [#53233](https://example.com/#53233) and https://k8s.io/#42", "See [#53233](https://example.com/#53233) and https://k8s.io/#42"},
+		{"<a href=\"#1234\">@user</a>", "<a href=\"#1234\">[@user](https://github.com/user)</a>"},
+		{"- [v1.8.0-rc.1](#v180-rc1)", "- [v1.8.0-rc.1](https://github.com/kubernetes/kubernetes/blob/master/CHANGELOG-1.8.md#v180-rc1)"},
+		{"```\n#1234 @user\n```\n#1234\n", "```\n#1234 @user\n```\n[#1234](" + issue + "1234)\n"},
+		{"~~~\n```\n#1\n~~~\n", "~~~\n```\n#1\n~~~\n"},
+		{"Escaped &#35; entity and #v181 anchor", "Escaped &#35; entity and #v181 anchor"},
+	}
+
+	for _, table := range tables {
+		if got := r.rewrite(table.markdown); got != table.want {
+			t.Errorf("%q: Rewritten markdown was incorrect, want:\n%s\ngot:\n%s", table.markdown, table.want, got)
+		}
+	}
+}
+
+func TestNotePRs(t *testing.T) {
+	notes := &ReleaseNotes{
+		Sections: []NoteSection{
+			{Entries: []NoteEntry{{Number: 1}}},
+			{Subsections: []NoteSection{{Entries: []NoteEntry{{Number: 2}}}}},
+		},
+		PendingPRs: []PendingPR{{Number: 3}},
+	}
+	if got := notePRs(notes); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("PRs were incorrect, want: %v, got: %v", []int{1, 2, 3}, got)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/google/go-github/github"
+	u "k8s.io/release/toolbox/util"
+)
+
+const (
+	releaseNoteLabel               = "release-note"
+	releaseNoteNoneLabel           = "release-note-none"
+	releaseNoteActionRequiredLabel = "release-note-action-required"
+)
+
+// lintResult contains the release note problems of a PR.
+type lintResult struct {
+	number   int
+	title    string
+	author   string
+	problems []string
+}
+
+// lintRange checks the release notes of all the PRs merged in input branch range, and writes
+// the checklist of PRs with problems to w. It returns the number of PRs with problems.
+func lintRange(g *u.GithubClient, w io.Writer, branchRange string, maxLength int) (int, error) {
+	log.Print("Gathering release commits from Github...")
+	commits, startTag, releaseTag, _, err := getReleaseCommits(g, *owner, *repo, *branch, branchRange)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get release commits for %s: %v", branchRange, err)
+	}
+	prs, err := u.ParsePRFromCommit(commits)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse release commits: %v", err)
+	}
+
+	log.Printf("Linting release notes of %d PRs...", len(prs))
+	results := make([]lintResult, 0)
+	for _, number := range prs {
+		pr, err := g.GetIssue(*owner, *repo, number)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get PR #%d: %v", number, err)
+		}
+		if problems := lintPR(pr, maxLength); len(problems) > 0 {
+			results = append(results, lintResult{number, pr.GetTitle(), pr.GetUser().GetLogin(), problems})
+		}
+	}
+
+	writeLintChecklist(w, fmt.Sprintf("%s..%s", startTag, releaseTag), len(prs), results)
+	return len(results), nil
+}
+
+// lintPR checks the release note labels and block of input PR, and returns the problems found.
+func lintPR(pr *github.Issue, maxLength int) []string {
+	problems := make([]string, 0)
+	hasNote := u.HasLabel(pr, releaseNoteLabel)
+	hasNone := u.HasLabel(pr, releaseNoteNoneLabel)
+	hasActionRequired := u.HasLabel(pr, releaseNoteActionRequiredLabel)
+	if !hasNote && !hasNone && !hasActionRequired {
+		problems = append(problems, fmt.Sprintf("missing %s, %s or %s label", releaseNoteLabel, releaseNoteNoneLabel, releaseNoteActionRequiredLabel))
+	}
+
+	note, actionRequired, found := parseReleaseNote(pr.GetBody())
+	switch {
+	case hasNone && note != "":
+		problems = append(problems, fmt.Sprintf("labelled %s but has a release note", releaseNoteNoneLabel))
+	case (hasNote || hasActionRequired) && found && note == "":
+		problems = append(problems, "labelled with a release note but the release note is NONE")
+	case hasNote && !hasActionRequired && actionRequired:
+		problems = append(problems, fmt.Sprintf("has a release-note-action-required block but is labelled %s", releaseNoteLabel))
+	}
+
+	if hasNote || hasActionRequired {
+		if !found || strings.EqualFold(strings.TrimSpace(note), strings.TrimSpace(pr.GetTitle())) {
+			problems = append(problems, "release note is just the PR title")
+		}
+	}
+	if maxLength > 0 && len(note) > maxLength {
+		problems = append(problems, fmt.Sprintf("release note is too long (%d > %d characters)", len(note), maxLength))
+	}
+	for _, d := range unbalancedMarkdown(note) {
+		problems = append(problems, fmt.Sprintf("release note has unbalanced markdown %s", d))
+	}
+	return problems
+}
+
+// unbalancedMarkdown returns the markdown delimiters (code spans, bold and link brackets) which
+// are not balanced in input text.
+func unbalancedMarkdown(text string) []string {
+	unbalanced := make([]string, 0)
+	if strings.Count(text, "`")%2 != 0 {
+		unbalanced = append(unbalanced, "`")
+	}
+
+	// Ignore the content of code spans for the other delimiters
+	var b bytes.Buffer
+	for i, s := range strings.Split(text, "`") {
+		if i%2 == 0 {
+			b.WriteString(s)
+		}
+	}
+	text = b.String()
+
+	if strings.Count(text, "**")%2 != 0 {
+		unbalanced = append(unbalanced, "**")
+	}
+	depth := 0
+	for _, c := range text {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+		if depth < 0 {
+			break
+		}
+	}
+	if depth != 0 {
+		unbalanced = append(unbalanced, "[]")
+	}
+	return unbalanced
+}
+
+// writeLintChecklist writes the markdown checklist of PRs with release note problems.
+func writeLintChecklist(w io.Writer, releaseRange string, total int, results []lintResult) {
+	var b bytes.Buffer
+	b.WriteString(fmt.Sprintf("## Release note lint for %s\n\n", releaseRange))
+	if len(results) == 0 {
+		b.WriteString(fmt.Sprintf("All %d PRs have valid release notes.\n", total))
+		io.WriteString(w, b.String())
+		return
+	}
+
+	b.WriteString(fmt.Sprintf("%d of %d PRs have release note problems:\n\n", len(results), total))
+	for _, r := range results {
+		b.WriteString(fmt.Sprintf("- [ ] #%d %s (@%s)\n", r.number, r.title, r.author))
+		for _, p := range r.problems {
+			b.WriteString(fmt.Sprintf("  - %s\n", p))
+		}
+	}
+	io.WriteString(w, b.String())
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLintPR(t *testing.T) {
+	long := strings.Repeat("a", 31)
+	tables := []struct {
+		title    string
+		body     string
+		labels   []string
+		problems []string
+	}{
+		{"Fix pods", "```release-note\nFixes a bug.\n```", []string{"release-note"}, []string{}},
+		{"Fix docs", "```release-note\nNONE\n```", []string{"release-note-none"}, []string{}},
+		{"Fix docs", "", []string{"release-note-none"}, []string{}},
+		{"Fix pods", "```release-note\nFixes a bug.\n```", nil,
+			[]string{"missing release-note, release-note-none or release-note-action-required label"}},
+		{"Fix pods", "```release-note\nFixes a bug.\n```", []string{"release-note-none"},
+			[]string{"labelled release-note-none but has a release note"}},
+		{"Fix pods", "```release-note\nNONE\n```", []string{"release-note"},
+			[]string{"labelled with a release note but the release note is NONE"}},
+		{"Drop --foo", "```release-note-action-required\nRemoves --foo.\n```", []string{"release-note"},
+			[]string{"has a release-note-action-required block but is labelled release-note"}},
+		{"Drop --foo", "```release-note-action-required\nRemoves --foo.\n```", []string{"release-note-action-required"}, []string{}},
+		{"Fix pods", "", []string{"release-note"}, []string{"release note is just the PR title"}},
+		{"Fix pods", "```release-note\nfix pods \n```", []string{"release-note"}, []string{"release note is just the PR title"}},
+		{"Fix pods", "```release-note\n" + long + "\n```", []string{"release-note"},
+			[]string{"release note is too long (31 > 30 characters)"}},
+		{"Fix pods", "```release-note\nFixes **`foo` [bar.\n```", []string{"release-note"},
+			[]string{"release note has unbalanced markdown **", "release note has unbalanced markdown []"}},
+	}
+
+	for i, table := range tables {
+		pr := newTestPR(1, "a", table.title, table.body, table.labels...)
+		if problems := lintPR(pr, 30); !reflect.DeepEqual(problems, table.problems) {
+			t.Errorf("%d: Problems were incorrect, want: %q, got: %q", i, table.problems, problems)
+		}
+	}
+}
+
+func TestUnbalancedMarkdown(t *testing.T) {
+	tables := []struct {
+		text string
+		want []string
+	}{
+		{"Adds the `--foo` flag, see [docs](https://k8s.io). **Important**", []string{}},
+		{"Adds the `--foo flag", []string{"`"}},
+		{"Adds the `**` operator", []string{}},
+		{"Fixes ]broken[ brackets", []string{"[]"}},
+		{"**Bold [link", []string{"**", "[]"}},
+	}
+
+	for _, table := range tables {
+		if got := unbalancedMarkdown(table.text); !reflect.DeepEqual(got, table.want) {
+			t.Errorf("%q: Unbalanced delimiters were incorrect, want: %q, got: %q", table.text, table.want, got)
+		}
+	}
+}
+
+func TestWriteLintChecklist(t *testing.T) {
+	var b bytes.Buffer
+	writeLintChecklist(&b, "v1.8.0..v1.8.1", 3, []lintResult{
+		{53233, "Fix pods", "liggitt", []string{"release note is just the PR title"}},
+	})
+	want := "## Release note lint for v1.8.0..v1.8.1\n\n1 of 3 PRs have release note problems:\n\n" +
+		"- [ ] #53233 Fix pods (@liggitt)\n  - release note is just the PR title\n"
+	if b.String() != want {
+		t.Errorf("Checklist was incorrect, want:\n%s\ngot:\n%s", want, b.String())
+	}
+
+	b.Reset()
+	writeLintChecklist(&b, "v1.8.0..v1.8.1", 3, nil)
+	if !strings.Contains(b.String(), "All 3 PRs have valid release notes.") {
+		t.Errorf("Expected valid release notes, got:\n%s", b.String())
+	}
+}
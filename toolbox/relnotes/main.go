@@ -16,17 +16,14 @@ package main
 
 import (
 	"bytes"
-	"context"
 	"flag"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
@@ -37,26 +34,58 @@ import (
 const (
 	k8sReleaseURLPrefix = "https://dl.k8s.io"
 	verDotzero          = "dotzero"
+
+	defaultCategories = "kind/feature=New Features,kind/bug=Bug Fixes,kind/deprecation=Deprecations," +
+		"kind/api-change=API Changes,kind/cleanup=Cleanups"
+	actionRequiredTitle = "Action Required"
+	uncategorizedTitle  = "Uncategorized"
 )
 
 var (
 	// Flags
 	// TODO: golang flags and parameters syntax
+	aggregateMinor   = flag.Bool("aggregate-minor", false, "For vX.Y.0 releases, categorize the notes of all PRs since vX.(Y-1).0 and merge them with the release notes draft")
+	apiChanges       = flag.Bool("api-changes", false, "Add a section listing OpenAPI spec changes between the start and release tags")
 	branch           = flag.String("branch", "", "Specify a branch other than the current one")
+	chatMessageSize  = flag.Int("chat-message-size", 4000, "Maximum size in characters of each message of --format=chat")
+	cherryPickLabels = flag.String("cherry-pick-labels", cherryPickApprovedLabel, "Comma-separated labels required on the pending PRs of release branches, reported by the preview subcommand")
+	categorize       = flag.Bool("categorize", false, "Group the notes of patch releases by category (see --categories) instead of a single list")
+	categoryMap      = flag.String("categories", defaultCategories, "Comma-separated label=title mapping of labels to the sections used by --categorize")
+	checksumsDir     = flag.String("checksums-dir", "", "Directory to write the <release>-SHA256SUMS/<release>-SHA512SUMS files of the download tables to, instead of the directory of --markdown-file")
+	dependencies     = flag.Bool("dependencies", false, "Add a section listing dependency changes between the start and release tags")
+	downloadURL      = flag.String("download-url", "", "Download URL of the release artifacts, followed by /<release tag>/<file> (defaults to the --release-bucket URL)")
+	downloadManifest = flag.String("download-manifest", "", "YAML or JSON manifest of the download tables (artifacts directory, URL and grouping rules), see --release-tars")
 	documentURL      = flag.String("doc-url", "https://docs.k8s.io", "Documentation URL displayed in release notes")
+	emailFrom        = flag.String("email-from", "", "From address of --format=email")
+	emailTo          = flag.String("email-to", "", "To address of --format=email")
+	enhancements     = flag.Bool("enhancements", false, "For vX.Y.0 releases, add a Major Themes by stage section listing the enhancements of the release milestone (see --features-repo) and their PRs")
 	exampleURLPrefix = flag.String("example-url-prefix", "https://releases.k8s.io/", "Example URL prefix displayed in release notes")
+	featuresRepo     = flag.String("features-repo", "kubernetes/features", "Github repository tracking the enhancements listed by --enhancements, as owner/repo")
 	full             = flag.Bool("full", false, "Force 'full' release format to show all sections of release notes. "+
 		"(This is the *default* for new branch X.Y.0 notes)")
-	githubToken   = flag.String("github-token", "", "The file that contains Github token. Must be specified, or set the GITHUB_TOKEN environment variable.")
-	htmlFileName  = flag.String("html-file", "", "Produce a html version of the notes")
-	htmlizeMD     = flag.Bool("htmlize-md", false, "Output markdown with html for PRs and contributors (for use in CHANGELOG.md)")
-	mdFileName    = flag.String("markdown-file", "", "Specify an alt file to use to store notes")
-	owner         = flag.String("owner", "kubernetes", "Github owner or organization")
-	preview       = flag.Bool("preview", false, "Report additional branch statistics (used for reporting outside of releases)")
-	quiet         = flag.Bool("quiet", false, "Don't display the notes when done")
-	releaseBucket = flag.String("release-bucket", "kubernetes-release", "Specify Google Storage bucket to point to in generated notes (informational only)")
-	releaseTars   = flag.String("release-tars", "", "Directory of tars to sha256 sum for display")
-	repo          = flag.String("repo", "kubernetes", "Github repository")
+	format          = flag.String("format", formatMarkdown, "Output format: markdown, json, text, chat (JSON list of mrkdwn messages), email (RFC 5322) or atom (feed entry)")
+	githubToken     = flag.String("github-token", "", "The file that contains Github token. Must be specified, or set the GITHUB_TOKEN environment variable.")
+	groupBySIG      = flag.Bool("group-by-sig", false, "Group the notes of each section by SIG (sig/* labels)")
+	htmlCSSFile     = flag.String("html-css", "", "Stylesheet file to embed in the html version of the notes, instead of the default one")
+	htmlFileName    = flag.String("html-file", "", "Produce a html version of the notes")
+	htmlizeMD       = flag.Bool("htmlize-md", false, "Output markdown with html for PRs and contributors (for use in CHANGELOG.md)")
+	knownIssues     = flag.Bool("known-issues", false, "For vX.Y.0 releases, fill the Known Issues section from open issues (see --known-issues-label) and the Deprecations section from kind/deprecation PRs")
+	issuesLabel     = flag.String("known-issues-label", "known-issue", "Label of the open issues listed by --known-issues")
+	issuesMilestone = flag.String("known-issues-milestone", "", "Milestone of the open issues listed by --known-issues and of the enhancements listed by --enhancements, defaults to the vX.Y of the release")
+	lint            = flag.Bool("lint", false, "Check the release notes of the PRs in range instead of generating notes, and exit non-zero on problems")
+	lintMaxLength   = flag.Int("lint-max-length", 500, "Maximum release note length in characters for --lint (0 for no limit)")
+	manifestFile    = flag.String("manifest", "", "YAML or JSON manifest of owner/repo and range pairs to gather combined release notes for, instead of the range argument")
+	mdFileName      = flag.String("markdown-file", "", "Specify an alt file to use to store notes (in the output format)")
+	overridesFile   = flag.String("overrides", "", "YAML or JSON file of PR-indexed release note overrides (text, section, actionRequired, drop)")
+	owner           = flag.String("owner", "kubernetes", "Github owner or organization")
+	pendingSort     = flag.String("pending-sort", pendingSortWaiting, "Order of the pending PRs of the preview subcommand: waiting (longest first), number, author, approved or checks")
+	preview         = flag.Bool("preview", false, "Report additional branch statistics (used for reporting outside of releases)")
+	quiet           = flag.Bool("quiet", false, "Don't display the notes when done")
+	releaseBucket   = flag.String("release-bucket", "kubernetes-release", "Specify Google Storage bucket to point to in generated notes (informational only)")
+	releaseTars     = flag.String("release-tars", "", "Directory of release artifacts to list with their hashes in the download tables, and in <release>-SHA256SUMS/<release>-SHA512SUMS files next to the notes")
+	repo            = flag.String("repo", "kubernetes", "Github repository")
+	repoDir         = flag.String("repo-dir", "", "Local clone of the repository to read files and the commits of cross-branch ranges from, instead of the Github API")
+	templateFile    = flag.String("template", "", "Go text/template file to render the markdown notes with, instead of the default layout")
 
 	// Global
 	branchHead      = ""
@@ -67,18 +96,25 @@ var (
 // NOTE: the prMap only includes PRs with "release-note" label.
 type ReleaseInfo struct {
 	startTag, releaseTag     string
+	branchHead               string
 	prMap                    map[int]*github.Issue
 	releasePRs               []int
 	releaseActionRequiredPRs []int
+	// rangePRs are all the PRs of the release commits, with or without release note
+	rangePRs []int
 }
 
 func main() {
 	// Initialization
 	flag.Parse()
+	if flag.Arg(0) == "diff" {
+		os.Exit(runDiff(flag.Args()[1:], os.Stdout, os.Stderr))
+	}
 	branchRange := flag.Arg(0)
 	startingTime := time.Now().Round(time.Second)
 
-	log.Printf("Boolean flags: full: %v, htmlize-md: %v, preview: %v, quiet: %v", *full, *htmlizeMD, *preview, *quiet)
+	log.Printf("Boolean flags: aggregate-minor: %v, api-changes: %v, categorize: %v, dependencies: %v, enhancements: %v, full: %v, group-by-sig: %v, htmlize-md: %v, known-issues: %v, lint: %v, preview: %v, quiet: %v",
+		*aggregateMinor, *apiChanges, *categorize, *dependencies, *enhancements, *full, *groupBySIG, *htmlizeMD, *knownIssues, *lint, *preview, *quiet)
 	log.Printf("Input branch range: %s", branchRange)
 
 	if *branch == "" {
@@ -93,11 +129,27 @@ func main() {
 	branchVerSuffix = strings.TrimPrefix(*branch, "release")
 	log.Printf("Working branch: %s. Branch version suffix: %s.", *branch, branchVerSuffix)
 
-	prFileName := fmt.Sprintf("/tmp/release-notes-%s-prnotes", *branch)
+	ext, ok := formatExtensions[*format]
+	if !ok {
+		log.Printf("unknown output format %q", *format)
+		os.Exit(1)
+	}
+	if *format == formatChat && *chatMessageSize <= 0 {
+		log.Printf("--chat-message-size must be positive")
+		os.Exit(1)
+	}
+	if *manifestFile != "" && (*aggregateMinor || *apiChanges || *dependencies || *enhancements || *groupBySIG || *knownIssues || *lint || *overridesFile != "" || *preview || *releaseTars != "" || *downloadManifest != "") {
+		log.Print("--manifest can't be combined with --aggregate-minor, --api-changes, --dependencies, --enhancements, --group-by-sig, --known-issues, --lint, --overrides, --preview, --release-tars or --download-manifest")
+		os.Exit(1)
+	}
 	if *mdFileName == "" {
-		*mdFileName = fmt.Sprintf("/tmp/release-notes-%s.md", *branch)
+		name := "release-notes"
+		if branchRange == "preview" {
+			name = "release-preview"
+		}
+		*mdFileName = fmt.Sprintf("/tmp/%s-%s.%s", name, *branch, ext)
 	}
-	log.Printf("Output markdown file path: %s", *mdFileName)
+	log.Printf("Output %s file path: %s", *format, *mdFileName)
 	if *htmlFileName != "" {
 		log.Printf("Output HTML file path: %s", *htmlFileName)
 	}
@@ -122,42 +174,122 @@ func main() {
 
 	// End of initialization
 
-	// Gather release related information including startTag, releaseTag, prMap and releasePRs
-	releaseInfo, err := gatherReleaseInfo(client, branchRange)
-	if err != nil {
-		log.Printf("failed to gather release related information: %v", err)
-		os.Exit(1)
+	if branchRange == "preview" {
+		if err := runPreview(client); err != nil {
+			log.Print(err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	// Generating release note...
-	log.Print("Generating release notes...")
-	err = gatherPRNotes(prFileName, releaseInfo)
-	if err != nil {
-		log.Printf("failed to gather PR notes: %v", err)
-		os.Exit(1)
+	if *lint {
+		problems, err := lintRange(client, os.Stdout, branchRange, *lintMaxLength)
+		if err != nil {
+			log.Printf("failed to lint release notes: %v", err)
+			os.Exit(1)
+		}
+		if problems > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	var notes *ReleaseNotes
+	if *manifestFile != "" {
+		manifest, err := loadManifest(*manifestFile)
+		if err != nil {
+			log.Print(err)
+			os.Exit(1)
+		}
+		log.Printf("Generating release notes of %d repositories...", len(manifest.Repos))
+		notes, err = gatherManifestNotes(client, manifest)
+		if err != nil {
+			log.Printf("failed to gather release notes: %v", err)
+			os.Exit(1)
+		}
+	} else {
+		if *aggregateMinor || *knownIssues || *enhancements {
+			// Minor release notes cover everything since the previous minor release, including
+			// the PRs already released in its alphas, betas and release candidates. So do its
+			// deprecations and enhancements
+			var err error
+			branchRange, err = minorRange(branchRange)
+			if err != nil {
+				log.Printf("failed to determine minor release range: %v", err)
+				os.Exit(1)
+			}
+			log.Printf("Aggregated minor release range: %s", branchRange)
+		}
+
+		// Gather release related information including startTag, releaseTag, prMap and releasePRs
+		releaseInfo, err := gatherReleaseInfo(client, *owner, *repo, *branch, branchRange)
+		if err != nil {
+			log.Printf("failed to gather release related information: %v", err)
+			os.Exit(1)
+		}
+		branchHead = releaseInfo.branchHead
+
+		// Generating release note...
+		log.Print("Generating release notes...")
+		notes, err = gatherReleaseNotes(client, releaseInfo)
+		if err != nil {
+			log.Printf("failed to gather release notes: %v", err)
+			os.Exit(1)
+		}
 	}
 
-	// Start generating markdown file
 	log.Print("Preparing layout...")
-	err = generateMDFile(client, releaseInfo.releaseTag, prFileName)
+	err := writeNotesFile(*mdFileName, *format, notes)
 	if err != nil {
-		log.Printf("failed to generate markdown file: %v", err)
+		log.Printf("failed to write release note file: %v", err)
 		os.Exit(1)
 	}
+	if len(notes.Downloads) > 0 {
+		// The checksum files go next to the notes, unless --checksums-dir is set
+		dir := *checksumsDir
+		if dir == "" {
+			dir = filepath.Dir(*mdFileName)
+		}
+		if err = writeChecksumFiles(dir, notes.Version, notes.Downloads); err != nil {
+			log.Print(err)
+			os.Exit(1)
+		}
+	}
 
-	if *htmlizeMD && !u.IsVer(releaseInfo.releaseTag, verDotzero) {
-		// HTML-ize markdown file
-		// Make users and PRs linkable
-		// Also, expand anchors (needed for email announce())
-		projectGithubURL := fmt.Sprintf("https://github.com/%s/%s", *owner, *repo)
-		_, err = u.Shell("sed", "-i", "-e", "s,#\\([0-9]\\{5\\,\\}\\),[#\\1]("+projectGithubURL+"/pull/\\1),g",
-			"-e", "s,\\(#v[0-9]\\{3\\}-\\),"+projectGithubURL+"/blob/master/CHANGELOG"+branchVerSuffix+".md\\1,g",
-			"-e", "s,@\\([a-zA-Z0-9-]*\\),[@\\1](https://github.com/\\1),g", *mdFileName)
+	if *format == formatMarkdown {
+		err = postProcessMarkdown(notes)
+		if err != nil {
+			log.Print(err)
+			os.Exit(1)
+		}
+	}
 
+	if !*quiet {
+		// If --quiet flag is not specified, print the release note to stdout
+		log.Printf("Displaying the %s release note to stdout...", *format)
+		dat, err := ioutil.ReadFile(*mdFileName)
 		if err != nil {
-			log.Printf("failed to htmlize markdown file: %v", err)
+			log.Printf("failed to read release note: %v", err)
 			os.Exit(1)
 		}
+		fmt.Print(string(dat))
+	}
+
+	log.Printf("Successfully generated release note. Total running time: %s", time.Now().Round(time.Second).Sub(startingTime).String())
+
+	return
+}
+
+// postProcessMarkdown runs the steps that operate on the generated markdown release note file:
+// htmlizing, appending CI job status and converting to HTML.
+func postProcessMarkdown(notes *ReleaseNotes) error {
+	if *htmlizeMD && !u.IsVer(notes.Version, verDotzero) {
+		// HTML-ize markdown file
+		// Make users, PRs, issues and commits linkable
+		// Also, expand anchors (needed for email announce())
+		if err := htmlizeFile(*mdFileName, newLinkRewriter(*owner, *repo, "CHANGELOG"+branchVerSuffix+".md", notePRs(notes))); err != nil {
+			return fmt.Errorf("failed to htmlize markdown file: %v", err)
+		}
 	}
 
 	if *preview && *owner == "kubernetes" && *repo == "kubernetes" {
@@ -168,58 +300,68 @@ func main() {
 		// NOTE: this function is Kubernetes-specified and runs the find_green_build script under
 		// kubernetes/release. Make sure you have the dependencies installed for find_green_build
 		// before running this function.
-		err = getCIJobStatus(*mdFileName, *branch, *htmlizeMD)
+		err := getCIJobStatus(*mdFileName, *branch, *htmlizeMD)
 		if err != nil {
-			log.Printf("failed to get CI status: %v", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to get CI status: %v", err)
 		}
 	}
 
 	if *htmlFileName != "" {
 		// If HTML file name is given, generate HTML release note
-		err = createHTMLNote(*htmlFileName, *mdFileName)
+		err := createHTMLNote(*htmlFileName, *mdFileName, *htmlCSSFile)
 		if err != nil {
-			log.Printf("failed to generate HTML release note: %v", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to generate HTML release note: %v", err)
 		}
 	}
+	return nil
+}
 
-	if !*quiet {
-		// If --quiet flag is not specified, print the markdown release note to stdout
-		log.Print("Displaying the markdown release note to stdout...")
-		dat, err := ioutil.ReadFile(*mdFileName)
-		if err != nil {
-			log.Printf("failed to read markdown release note: %v", err)
-			os.Exit(1)
-		}
-		fmt.Print(string(dat))
+// htmlizeFile rewrites the references of input markdown file into links.
+func htmlizeFile(filename string, r *linkRewriter) error {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
 	}
-
-	log.Printf("Successfully generated release note. Total running time: %s", time.Now().Round(time.Second).Sub(startingTime).String())
-
-	return
+	return ioutil.WriteFile(filename, []byte(r.rewrite(string(content))), 0644)
 }
 
-func gatherReleaseInfo(g *u.GithubClient, branchRange string) (*ReleaseInfo, error) {
+// gatherReleaseInfo gathers the release note PRs of input branch range in owner/repo. Ranges of
+// two refs which aren't release tags of the branch, e.g. "v1.7.8..v1.8.2", are resolved by
+// reachability (see rangePRs) rather than on the branch. It is safe to call concurrently for
+// different repositories.
+func gatherReleaseInfo(g *u.GithubClient, owner, repo, branch, branchRange string) (*ReleaseInfo, error) {
 	var info ReleaseInfo
-	log.Print("Gathering release commits from Github...")
-	// Get release related commits on the release branch within release range
-	releaseCommits, startTag, releaseTag, err := getReleaseCommits(g, *owner, *repo, *branch, branchRange)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get release commits for %s: %v", branchRange, err)
-	}
-	info.startTag = startTag
-	info.releaseTag = releaseTag
+	var commitPRs []int
+	if start, end, ok := splitRange(branchRange); ok && !onBranch(branch, start, end) {
+		log.Printf("Gathering %s/%s PRs reachable from %s but not from %s...", owner, repo, end, start)
+		var err error
+		commitPRs, err = rangePRs(g, owner, repo, cloneDir(owner, repo), start, end)
+		if err != nil {
+			return nil, err
+		}
+		info.startTag = start
+		info.releaseTag = end
+	} else {
+		log.Printf("Gathering %s/%s release commits from Github...", owner, repo)
+		// Get release related commits on the release branch within release range
+		releaseCommits, startTag, releaseTag, head, err := getReleaseCommits(g, owner, repo, branch, branchRange)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get release commits for %s: %v", branchRange, err)
+		}
+		info.startTag = startTag
+		info.releaseTag = releaseTag
+		info.branchHead = head
 
-	// Parse release related PR ids from the release commits
-	commitPRs, err := parsePRFromCommit(releaseCommits)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse release commits: %v", err)
+		// Parse release related PR ids from the release commits
+		commitPRs, err = u.ParsePRFromCommit(releaseCommits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse release commits: %v", err)
+		}
 	}
 
 	log.Print("Gathering \"release-note\" labelled PRs using Github search API. This may take a while...")
 	var query []string
-	query = u.AddQuery(query, "repo", *owner, "/", *repo)
+	query = u.AddQuery(query, "repo", owner, "/", repo)
 	query = u.AddQuery(query, "type", "pr")
 	query = u.AddQuery(query, "label", "release-note")
 	releaseNotePRs, err := g.SearchIssues(strings.Join(query, " "))
@@ -230,7 +372,7 @@ func gatherReleaseInfo(g *u.GithubClient, branchRange string) (*ReleaseInfo, err
 
 	log.Print("Gathering \"release-note-action-required\" labelled PRs using Github search API.")
 	query = nil
-	query = u.AddQuery(query, "repo", *owner, "/", *repo)
+	query = u.AddQuery(query, "repo", owner, "/", repo)
 	query = u.AddQuery(query, "type", "pr")
 	query = u.AddQuery(query, "label", "release-note-action-required")
 	releaseNoteActionRequiredPRs, err := g.SearchIssues(strings.Join(query, " "))
@@ -254,15 +396,8 @@ func gatherReleaseInfo(g *u.GithubClient, branchRange string) (*ReleaseInfo, err
 	}
 
 	// Get release note PRs by examining release-note label on commit PRs
-	info.releasePRs = make([]int, 0)
-	for _, pr := range commitPRs {
-		if info.prMap[pr] != nil {
-			info.releasePRs = append(info.releasePRs, pr)
-		}
-		if actionRequiredPRMap[pr] != nil {
-			info.releaseActionRequiredPRs = append(info.releaseActionRequiredPRs, pr)
-		}
-	}
+	info.releasePRs, info.releaseActionRequiredPRs = classifyReleasePRs(commitPRs, info.prMap, actionRequiredPRMap)
+	info.rangePRs = commitPRs
 
 	for k, v := range actionRequiredPRMap {
 		info.prMap[k] = v
@@ -271,75 +406,11 @@ func gatherReleaseInfo(g *u.GithubClient, branchRange string) (*ReleaseInfo, err
 	return &info, nil
 }
 
-func gatherPRNotes(prFileName string, info *ReleaseInfo) error {
-	var result error
-	prFile, err := os.Create(prFileName)
-	if err != nil {
-		return fmt.Errorf("failed to create release note file %s: %v", prFileName, err)
-	}
-	defer func() {
-		if err = prFile.Close(); err != nil {
-			result = fmt.Errorf("failed to close file %s, %v", prFileName, err)
-		}
-	}()
-
-	// Bootstrap notes for minor (new branch) releases
-	if *full || u.IsVer(info.releaseTag, verDotzero) {
-		draftURL := fmt.Sprintf("%s%s/features/master/%s/release-notes-draft.md", u.GithubRawURL, *owner, *branch)
-		changelogURL := fmt.Sprintf("%s%s/%s/master/CHANGELOG%s.md", u.GithubRawURL, *owner, *repo, branchVerSuffix)
-		minorRelease(prFile, info.releaseTag, draftURL, changelogURL)
-	} else {
-		patchRelease(prFile, info)
-	}
-	return result
-}
-
-func generateMDFile(g *u.GithubClient, releaseTag, prFileName string) error {
-	var result error
-	mdFile, err := os.Create(*mdFileName)
-	if err != nil {
-		return fmt.Errorf("failed to create release note markdown file %s: %v", *mdFileName, err)
-	}
-	defer func() {
-		if err = mdFile.Close(); err != nil {
-			result = fmt.Errorf("failed to close file %s, %v", *mdFileName, err)
-		}
-	}()
-
-	// Create markdown file body with documentation and example URLs from program flags
-	exampleURL := fmt.Sprintf("%s%s/examples", *exampleURLPrefix, *branch)
-	err = createBody(mdFile, releaseTag, *branch, *documentURL, exampleURL, *releaseTars)
-	if err != nil {
-		return fmt.Errorf("failed to create file body: %v", err)
-	}
-
-	// Copy (append) the pull request notes into the output markdown file
-	dat, err := ioutil.ReadFile(prFileName)
-	if err != nil {
-		return fmt.Errorf("failed to copy from PR file to release note markdown file: %v", err)
-	}
-	mdFile.WriteString(string(dat))
-
-	if *preview {
-		// If in preview mode, get the pending PRs
-		err = getPendingPRs(g, mdFile, *owner, *repo, *branch)
-		if err != nil {
-			return fmt.Errorf("failed to get pending PRs: %v", err)
-		}
-	}
-	return result
-}
-
-// getPendingPRs gets pending PRs on given branch in the repo.
-func getPendingPRs(g *u.GithubClient, f *os.File, owner, repo, branch string) error {
+// listPendingPRs lists pending PRs on given branch in the repo. With status, their review and CI
+// status is gathered as well, against the required status checks of the branch and, for release
+// branches, the --cherry-pick-labels.
+func listPendingPRs(g *u.GithubClient, owner, repo, branch string, status bool) ([]PendingPR, error) {
 	log.Print("Getting pending PR status...")
-	f.WriteString("-------\n")
-	f.WriteString(fmt.Sprintf("## PENDING PRs on the %s branch\n", branch))
-
-	if *htmlizeMD {
-		f.WriteString("PR | Milestone | User | Date | Commit Message\n")
-		f.WriteString("-- | --------- | ---- | ---- | --------------\n")
-	}
 
 	var query []string
 	query = u.AddQuery(query, "repo", owner, "/", repo)
@@ -348,67 +419,47 @@ func getPendingPRs(g *u.GithubClient, f *os.File, owner, repo, branch string) er
 	query = u.AddQuery(query, "base", branch)
 	pendingPRs, err := g.SearchIssues(strings.Join(query, " "))
 	if err != nil {
-		return fmt.Errorf("failed to search pending PRs: %v", err)
+		return nil, fmt.Errorf("failed to search pending PRs: %v", err)
 	}
 
-	for _, pr := range pendingPRs {
-		var str string
-		// escape '*' in commit messages so they don't mess up formatting
-		msg := strings.Replace(*pr.Title, "*", "", -1)
-		milestone := "null"
-		if pr.Milestone != nil {
-			milestone = *pr.Milestone.Title
+	var requiredLabels, requiredChecks []string
+	if status {
+		if strings.HasPrefix(branch, "release-") {
+			for _, l := range strings.Split(*cherryPickLabels, ",") {
+				if l = strings.TrimSpace(l); l != "" {
+					requiredLabels = append(requiredLabels, l)
+				}
+			}
 		}
-		if *htmlizeMD {
-			str = fmt.Sprintf("#%-8d | %-4s | @%-10s| %s   | %s\n", *pr.Number, milestone, *pr.User.Login, pr.UpdatedAt.Format("Mon Jan  2 15:04:05 MST 2006"), msg)
-		} else {
-			str = fmt.Sprintf("#%-8d  %-4s  @%-10s %s    %s\n", *pr.Number, milestone, *pr.User.Login, pr.UpdatedAt.Format("Mon Jan  2 15:04:05 MST 2006"), msg)
+		requiredChecks, err = g.RequiredStatusChecks(owner, repo, branch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get required status checks of %s: %v", branch, err)
 		}
-		f.WriteString(str)
-	}
-	f.WriteString("\n\n")
-	return nil
-}
-
-// createHTMLNote generates HTML release note based on the input markdown release note.
-func createHTMLNote(htmlFileName, mdFileName string) error {
-	var result error
-	log.Print("Generating HTML release note...")
-	cssFileName := "/tmp/release_note_cssfile"
-	cssFile, err := os.Create(cssFileName)
-	if err != nil {
-		return fmt.Errorf("failed to create css file %s: %v", cssFileName, err)
-	}
-
-	cssFile.WriteString("<style type=text/css> ")
-	cssFile.WriteString("table,th,tr,td {border: 1px solid gray; ")
-	cssFile.WriteString("border-collapse: collapse;padding: 5px;} ")
-	cssFile.WriteString("</style>")
-	// Here we manually close the css file instead of defer the close function,
-	// because we need to use the css file for pandoc command below.
-	// Writing to css file is a clear small logic so we don't separate it into
-	// another function.
-	if err = cssFile.Close(); err != nil {
-		return fmt.Errorf("failed to close file %s, %v", cssFileName, err)
-	}
-
-	htmlStr, err := u.Shell("pandoc", "-H", cssFileName, "--from", "markdown_github", "--to", "html", mdFileName)
-	if err != nil {
-		return fmt.Errorf("failed to generate html content: %v", err)
 	}
 
-	htmlFile, err := os.Create(htmlFileName)
-	if err != nil {
-		return fmt.Errorf("failed to create html file: %v", err)
+	if status {
+		log.Printf("Getting review and CI status of %d pending PRs...", len(pendingPRs))
 	}
-	defer func() {
-		if err = htmlFile.Close(); err != nil {
-			result = fmt.Errorf("failed to close file %s, %v", htmlFileName, err)
+	prs := make([]PendingPR, 0, len(pendingPRs))
+	for i, pr := range pendingPRs {
+		milestone := "null"
+		if pr.Milestone != nil {
+			milestone = *pr.Milestone.Title
 		}
-	}()
-
-	htmlFile.WriteString(htmlStr)
-	return result
+		labels := make([]string, 0, len(pr.Labels))
+		for _, l := range pr.Labels {
+			labels = append(labels, l.GetName())
+		}
+		p := PendingPR{*pr.Number, milestone, *pr.User.Login, *pr.UpdatedAt, *pr.Title, labels, nil}
+		if status {
+			p.Status, err = g.GetPRStatus(owner, repo, &pendingPRs[i], requiredLabels, requiredChecks)
+			if err != nil {
+				return nil, err
+			}
+		}
+		prs = append(prs, p)
+	}
+	return prs, nil
 }
 
 // getCIJobStatus runs the script find_green_build and append CI job status to outputFile.
@@ -417,7 +468,6 @@ func createHTMLNote(htmlFileName, mdFileName string) error {
 // before running this function.
 func getCIJobStatus(outputFile, branch string, htmlize bool) error {
 	var result error
-	log.Print("Getting CI job status (this may take a while)...")
 
 	red := "<span style=\"color:red\">"
 	green := "<span style=\"color:green\">"
@@ -429,20 +479,6 @@ func getCIJobStatus(outputFile, branch string, htmlize bool) error {
 		off = "</FONT>"
 	}
 
-	var extraFlag string
-
-	if strings.Contains(branch, "release-") {
-		// If working on a release branch assume --official for the purpose of displaying
-		// find_green_build output
-		extraFlag = "--official"
-	} else {
-		// For master branch, limit the analysis to 30 primary ci jobs. This is necessary
-		// due to the recently expanded blocking test list for master. The expanded test
-		// list is often unable to find a complete passing set and find_green_build runs
-		// unbounded for hours
-		extraFlag = "--limit=30"
-	}
-
 	f, err := os.OpenFile(outputFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		return err
@@ -455,9 +491,8 @@ func getCIJobStatus(outputFile, branch string, htmlize bool) error {
 
 	f.WriteString(fmt.Sprintf("## State of %s branch\n", branch))
 
-	// Call script find_green_build to get CI job status
-	content, err := u.Shell(os.Getenv("GOPATH")+"/src/k8s.io/release/find_green_build", "-v", extraFlag, branch)
-	if err == nil {
+	ok, content := findGreenBuild(branch)
+	if ok {
 		f.WriteString(fmt.Sprintf("%sGOOD TO GO!%s\n\n", green, off))
 	} else {
 		f.WriteString(fmt.Sprintf("%sNOT READY%s\n\n", red, off))
@@ -466,99 +501,40 @@ func getCIJobStatus(outputFile, branch string, htmlize bool) error {
 	f.WriteString("### Details\n```\n")
 	f.WriteString(content)
 	f.WriteString("```\n")
-
-	log.Print("CI job status fetched.")
 	return result
 }
 
-// createBody creates the general documentation, example and downloads table body for the
-// markdown file.
-func createBody(f *os.File, releaseTag, branch, docURL, exampleURL, releaseTars string) error {
-	var title string
-	if *preview {
-		title = "Branch "
-	}
-
-	if releaseTag == "HEAD" || releaseTag == branchHead {
-		title += branch
-	} else {
-		title += releaseTag
-	}
-
-	if *preview {
-		f.WriteString(fmt.Sprintf("**Release Note Preview - generated on %s**\n", time.Now().Format("Mon Jan  2 15:04:05 MST 2006")))
-	}
-
-	f.WriteString(fmt.Sprintf("\n# %s\n\n", title))
-	f.WriteString(fmt.Sprintf("[Documentation](%s) & [Examples](%s)\n\n", docURL, exampleURL))
-
-	if releaseTars != "" {
-		f.WriteString(fmt.Sprintf("## Downloads for %s\n\n", title))
-		tables := []struct {
-			heading  string
-			filename []string
-		}{
-			{"", []string{releaseTars + "/kubernetes.tar.gz", releaseTars + "/kubernetes-src.tar.gz"}},
-			{"Client Binaries", []string{releaseTars + "/kubernetes-client*.tar.gz"}},
-			{"Server Binaries", []string{releaseTars + "/kubernetes-server*.tar.gz"}},
-			{"Node Binaries", []string{releaseTars + "/kubernetes-node*.tar.gz"}},
-		}
-
-		for _, table := range tables {
-			err := createDownloadsTable(f, releaseTag, table.heading, table.filename...)
-			if err != nil {
-				return fmt.Errorf("failed to create downloads table: %v", err)
-			}
-		}
-		f.WriteString("\n")
-	}
-	return nil
-}
+// findGreenBuild runs the script find_green_build on input branch, and returns whether its CI
+// jobs are green along with the script output.
+// NOTE: this function is Kubernetes-specified, see getCIJobStatus.
+func findGreenBuild(branch string) (bool, string) {
+	log.Print("Getting CI job status (this may take a while)...")
 
-// createDownloadTable creates table of download link and sha256 hash for given file.
-func createDownloadsTable(f *os.File, releaseTag, heading string, filename ...string) error {
-	var urlPrefix string
+	var extraFlag string
 
-	if *releaseBucket == "kubernetes-release" {
-		urlPrefix = k8sReleaseURLPrefix
+	if strings.Contains(branch, "release-") {
+		// If working on a release branch assume --official for the purpose of displaying
+		// find_green_build output
+		extraFlag = "--official"
 	} else {
-		urlPrefix = fmt.Sprintf("https://storage.googleapis.com/%s/release", *releaseBucket)
-	}
-
-	if *releaseBucket == "" {
-		log.Print("NOTE: empty Google Storage bucket specified. Please specify valid bucket using \"release-bucket\" flag.")
-	}
-
-	if heading != "" {
-		f.WriteString(fmt.Sprintf("\n### %s\n", heading))
+		// For master branch, limit the analysis to 30 primary ci jobs. This is necessary
+		// due to the recently expanded blocking test list for master. The expanded test
+		// list is often unable to find a complete passing set and find_green_build runs
+		// unbounded for hours
+		extraFlag = "--limit=30"
 	}
 
-	f.WriteString("\n")
-	f.WriteString("filename | sha256 hash\n")
-	f.WriteString("-------- | -----------\n")
-
-	files := make([]string, 0)
-	for _, name := range filename {
-		fs, _ := filepath.Glob(name)
-		for _, v := range fs {
-			files = append(files, v)
-		}
-	}
+	// Call script find_green_build to get CI job status
+	content, err := u.Shell(os.Getenv("GOPATH")+"/src/k8s.io/release/find_green_build", "-v", extraFlag, branch)
 
-	for _, file := range files {
-		fn := filepath.Base(file)
-		sha, err := u.GetSha256(file)
-		if err != nil {
-			return fmt.Errorf("failed to calc SHA256 of file %s: %v", file, err)
-		}
-		f.WriteString(fmt.Sprintf("[%s](%s/%s/%s) | `%s`\n", fn, urlPrefix, releaseTag, fn, sha))
-	}
-	return nil
+	log.Print("CI job status fetched.")
+	return err == nil, content
 }
 
-// minorReleases performs a minor (vX.Y.0) release by fetching the release template and aggregate
-// previous release in series.
-func minorRelease(f *os.File, release, draftURL, changelogURL string) {
+// minorRelease gathers the notes of a minor (vX.Y.0) release by fetching the release draft and
+// aggregating previous releases in series. An empty draft means no draft was found, and nil
+// previous releases means the changelog couldn't be fetched.
+func minorRelease(release, draftURL, changelogURL string) (draft string, previous []string) {
 	// Check for draft and use it if available
 	log.Printf("Checking if draft release notes exist for %s...", release)
 
@@ -569,12 +545,12 @@ func minorRelease(f *os.File, release, draftURL, changelogURL string) {
 
 	if err == nil && resp.StatusCode == 200 {
 		log.Print("Draft found - using for release notes...")
-		_, err = io.Copy(f, resp.Body)
-		if err != nil {
-			log.Printf("error during copy to file: %v", err)
-			return
+		buf := new(bytes.Buffer)
+		if _, err = buf.ReadFrom(resp.Body); err != nil {
+			log.Printf("error during reading draft: %v", err)
+		} else {
+			draft = buf.String()
 		}
-		f.WriteString("\n")
 	} else {
 		log.Print("Failed to find draft - creating generic template... (error message/status code printed below)")
 		if err != nil {
@@ -582,12 +558,8 @@ func minorRelease(f *os.File, release, draftURL, changelogURL string) {
 		} else {
 			log.Printf("Response status code: %d", resp.StatusCode)
 		}
-		f.WriteString("## Major Themes\n\n* TBD\n\n## Other notable improvements\n\n* TBD\n\n## Known Issues\n\n* TBD\n\n## Provider-specific Notes\n\n* TBD\n\n")
 	}
 
-	// Aggregate all previous release in series
-	f.WriteString(fmt.Sprintf("### Previous Release Included in %s\n\n", release))
-
 	// Regexp Example:
 	// Assume the release tag is v1.7.0, this regexp matches "- [v1.7.0-" in
 	//     "- [v1.7.0-rc.1](#v170-rc1)"
@@ -603,12 +575,12 @@ func minorRelease(f *os.File, release, draftURL, changelogURL string) {
 	if err == nil && resp.StatusCode == 200 {
 		buf := new(bytes.Buffer)
 		buf.ReadFrom(resp.Body)
+		previous = make([]string, 0)
 		for _, line := range strings.Split(buf.String(), "\n") {
 			if anchor := reAnchor.FindStringSubmatch(line); anchor != nil {
-				f.WriteString(line + "\n")
+				previous = append(previous, line)
 			}
 		}
-		f.WriteString("\n")
 	} else {
 		log.Print("Failed to fetch past changelog for minor release - continuing... (error message/status code printed below)")
 		if err != nil {
@@ -617,180 +589,66 @@ func minorRelease(f *os.File, release, draftURL, changelogURL string) {
 			log.Printf("Response status code: %d", resp.StatusCode)
 		}
 	}
+	return draft, previous
 }
 
-// patchRelease performs a patch (vX.Y.Z) release by printing out all the related changes.
-func patchRelease(f *os.File, info *ReleaseInfo) {
-	// Release note for different labels
-	f.WriteString(fmt.Sprintf("## Changelog since %s\n\n", info.startTag))
-
-	if len(info.releaseActionRequiredPRs) > 0 {
-		f.WriteString("### Action Required\n\n")
-		for _, pr := range info.releaseActionRequiredPRs {
-			f.WriteString(fmt.Sprintf("* %s (#%d, @%s)\n", extractReleaseNoteFromPR(info.prMap[pr]), pr, *info.prMap[pr].User.Login))
+// classifyReleasePRs splits input commit PRs into release note PRs and action required PRs, by
+// their label or the kind of their release note block. PRs whose release note block explicitly
+// states NONE are dropped.
+func classifyReleasePRs(commitPRs []int, prMap, actionRequiredPRMap map[int]*github.Issue) (releasePRs, actionRequiredPRs []int) {
+	releasePRs = make([]int, 0)
+	for _, pr := range commitPRs {
+		if actionRequiredPRMap[pr] != nil {
+			actionRequiredPRs = append(actionRequiredPRs, pr)
+			continue
 		}
-		f.WriteString("\n")
-	}
-
-	if len(info.releasePRs) > 0 {
-		f.WriteString("### Other notable changes\n\n")
-		for _, pr := range info.releasePRs {
-			f.WriteString(fmt.Sprintf("* %s (#%d, @%s)\n", extractReleaseNoteFromPR(info.prMap[pr]), pr, *info.prMap[pr].User.Login))
+		if prMap[pr] == nil {
+			continue
+		}
+		note, actionRequired, found := parseReleaseNote(prMap[pr].GetBody())
+		switch {
+		case found && note == "":
+			log.Printf("Skipping PR #%d: release note is NONE", pr)
+		case actionRequired:
+			actionRequiredPRs = append(actionRequiredPRs, pr)
+		default:
+			releasePRs = append(releasePRs, pr)
 		}
-		f.WriteString("\n")
-	} else {
-		f.WriteString("**No notable changes for this release**\n\n")
 	}
+	return releasePRs, actionRequiredPRs
 }
 
 // extractReleaseNoteFromPR tries to fetch release note from PR body, otherwise uses PR title.
+// See parseReleaseNote for the format of the release note block in Kubernetes pull request
+// template: https://github.com/kubernetes/kubernetes/blob/master/.github/PULL_REQUEST_TEMPLATE.md
 func extractReleaseNoteFromPR(pr *github.Issue) string {
-	// Regexp Example:
-	// This regexp matches the release note section in Kubernetes pull request template:
-	// https://github.com/kubernetes/kubernetes/blob/master/.github/PULL_REQUEST_TEMPLATE.md
-	re, _ := regexp.Compile("```release-note\r\n(.+)\r\n```")
-	if note := re.FindStringSubmatch(*pr.Body); note != nil {
-		return note[1]
+	if note, _, found := parseReleaseNote(pr.GetBody()); found && note != "" {
+		return note
 	}
-	return *pr.Title
+	return pr.GetTitle()
 }
 
 // determineRange examines a Git branch range in the format of [[startTag..]endTag], and
-// determines a valid range. For example:
-//
-//     ""                       - last release to HEAD on the branch
-//     "v1.1.4.."               - v1.1.4 to HEAD
-//     "v1.1.4..v1.1.7"         - v1.1.4 to v1.1.7
-//     "v1.1.7"                 - last release on the branch to v1.1.7
-//
-// NOTE: the input branch must be the corresponding release branch w.r.t. input range. For example:
-//
-//     Getting "v1.1.4..v1.1.7" on branch "release-1.1" makes sense
-//     Getting "v1.1.4..v1.1.7" on branch "release-1.2" doesn't
-func determineRange(g *u.GithubClient, owner, repo, branch, branchRange string) (startTag, releaseTag string, err error) {
-	b, _, err := g.GetBranch(context.Background(), owner, repo, branch)
-	if err != nil {
-		return "", "", err
-	}
-	branchHead = *b.Commit.SHA
-
-	lastRelease, err := g.LastReleases(owner, repo)
-	if err != nil {
-		return "", "", err
-	}
-
-	// If lastRelease[branch] is unset, attempt to get the last release from the parent branch
-	// and then master
-	if i := strings.LastIndex(branch, "."); lastRelease[branch] == "" && i != -1 {
-		lastRelease[branch] = lastRelease[branch[:i]]
-	}
-	if lastRelease[branch] == "" {
-		lastRelease[branch] = lastRelease["master"]
-	}
-
-	// Regexp Example:
-	// This regexp matches the Git branch range in the format of [[startTag..]endTag]. For example:
-	//
-	//     ""
-	//     "v1.1.4.."
-	//     "v1.1.4..v1.1.7"
-	//     "v1.1.7"
-	re, _ := regexp.Compile("([v0-9.]*-*(alpha|beta|rc)*\\.*[0-9]*)\\.\\.([v0-9.]*-*(alpha|beta|rc)*\\.*[0-9]*)$")
-	tags := re.FindStringSubmatch(branchRange)
-	if tags != nil {
-		startTag = tags[1]
-		releaseTag = tags[3]
-	} else {
-		startTag = lastRelease[branch]
-		releaseTag = branchHead
-	}
-
-	if startTag == "" {
-		return "", "", fmt.Errorf("unable to set beginning of range automatically")
-	}
-	if releaseTag == "" {
-		releaseTag = branchHead
-	}
-
-	return startTag, releaseTag, nil
+// determines a valid range. See GithubClient.DetermineRange for details. The branch HEAD is
+// returned as well.
+func determineRange(g *u.GithubClient, owner, repo, branch, branchRange string) (startTag, releaseTag, head string, err error) {
+	return g.DetermineRange(owner, repo, branch, branchRange)
 }
 
 // getReleaseCommits given a Git branch range in the format of [[startTag..]endTag], determines
-// a valid range and returns all the commits on the branch in that range.
-func getReleaseCommits(g *u.GithubClient, owner, repo, branch, branchRange string) ([]*github.RepositoryCommit, string, string, error) {
+// a valid range and returns all the commits on the branch in that range, along with the branch
+// HEAD.
+func getReleaseCommits(g *u.GithubClient, owner, repo, branch, branchRange string) ([]*github.RepositoryCommit, string, string, string, error) {
 	// Get start and release tag/commit based on input branch range
-	startTag, releaseTag, err := determineRange(g, owner, repo, branch, branchRange)
-	if err != nil {
-		return nil, "", "", fmt.Errorf("failed to determine branch range: %v", err)
-	}
-
-	// Get all tags in the repository
-	tags, err := g.ListAllTags(owner, repo)
-	if err != nil {
-		return nil, "", "", fmt.Errorf("failed to fetch repo tags: %v", err)
-	}
-
-	// Get commits for specified branch and range
-	tStart, err := g.GetCommitDate(owner, repo, startTag, tags)
+	startTag, releaseTag, head, err := determineRange(g, owner, repo, branch, branchRange)
 	if err != nil {
-		return nil, "", "", fmt.Errorf("failed to get start commit date for %s: %v", startTag, err)
-	}
-	tEnd, err := g.GetCommitDate(owner, repo, releaseTag, tags)
-	if err != nil {
-		return nil, "", "", fmt.Errorf("failed to get release commit date for %s: %v", releaseTag, err)
+		return nil, "", "", "", fmt.Errorf("failed to determine branch range: %v", err)
 	}
 
-	releaseCommits, err := g.ListAllCommits(owner, repo, branch, tStart, tEnd)
+	releaseCommits, err := g.ListReleaseCommits(owner, repo, branch, startTag, releaseTag)
 	if err != nil {
-		return nil, "", "", fmt.Errorf("failed to fetch release repo commits: %v", err)
+		return nil, "", "", "", err
 	}
 
-	return releaseCommits, startTag, releaseTag, nil
-}
-
-// parsePRFromCommit goes through commit messages, and parse PR IDs for normal pull requests as
-// well as cherry picks.
-func parsePRFromCommit(commits []*github.RepositoryCommit) ([]int, error) {
-	prs := make([]int, 0)
-	prsMap := make(map[int]bool)
-
-	// Regexp example:
-	// This regexp matches (Note that it supports multiple-source cherry pick)
-	//
-	// "automated-cherry-pick-of-#12345-#23412-"
-	// "automated-cherry-pick-of-#23791-"
-	reCherry, _ := regexp.Compile("automated-cherry-pick-of-(#[0-9]+-){1,}")
-	reCherryID, _ := regexp.Compile("#([0-9]+)-")
-	reMerge, _ := regexp.Compile("^Merge pull request #([0-9]+) from")
-
-	for _, c := range commits {
-		// Deref all PRs back to master
-		// Match cherry pick PRs first and then normal pull requests
-		// Paying special attention to automated cherrypicks that could have multiple
-		// sources
-		if cpStr := reCherry.FindStringSubmatch(*c.Commit.Message); cpStr != nil {
-			cpPRs := reCherryID.FindAllStringSubmatch(cpStr[0], -1)
-			for _, pr := range cpPRs {
-				id, err := strconv.Atoi(pr[1])
-				if err != nil {
-					return nil, err
-				}
-				if prsMap[id] == false {
-					prs = append(prs, id)
-					prsMap[id] = true
-				}
-			}
-		} else if pr := reMerge.FindStringSubmatch(*c.Commit.Message); pr != nil {
-			id, err := strconv.Atoi(pr[1])
-			if err != nil {
-				return nil, err
-			}
-			if prsMap[id] == false {
-				prs = append(prs, id)
-				prsMap[id] = true
-			}
-		}
-	}
-
-	return prs, nil
+	return releaseCommits, startTag, releaseTag, head, nil
 }
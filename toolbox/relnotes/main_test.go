@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"os"
 	"regexp"
 	"strconv"
@@ -29,7 +30,7 @@ func TestDetermineRange(t *testing.T) {
 	c := u.NewClient(githubToken)
 
 	for _, table := range tables {
-		s, e, err := determineRange(c, table.owner, table.repo, table.branch, table.branchRange)
+		s, e, _, err := determineRange(c, table.owner, table.repo, table.branch, table.branchRange)
 		if err != nil {
 			t.Errorf("%v %v: Unexpected error: %v", table.branch, table.branchRange, err)
 		}
@@ -142,13 +143,27 @@ func TestCreateBody(t *testing.T) {
 		t.Errorf("Unexpected error: %v", err)
 	}
 
-	createBody(f, releaseTag, branch, docURL, exampleURL, releaseTars)
+	downloads, err := getDownloads(releaseTag, releaseTars, "")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	notes := &ReleaseNotes{
+		Title:      releaseTitle(releaseTag, branch, false),
+		Version:    releaseTag,
+		Branch:     branch,
+		DocURL:     docURL,
+		ExampleURL: exampleURL,
+		Downloads:  downloads,
+	}
+	var b bytes.Buffer
+	renderBlock(&b, "body", notes)
+	f.Write(b.Bytes())
 }
 
 func TestCreateHTMLNote(t *testing.T) {
 	htmlFileName := "/tmp/release_note_tests_html_testfile"
 	mdFileName := "/tmp/relnotes-release-1.7.md"
-	err := createHTMLNote(htmlFileName, mdFileName)
+	err := createHTMLNote(htmlFileName, mdFileName, "")
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -168,8 +183,9 @@ func TestGetPendingPRs(t *testing.T) {
 	githubToken := os.Getenv("GITHUB_TOKEN")
 	c := u.NewClient(githubToken)
 
-	err = getPendingPRs(c, f, owner, repo, branch)
+	prs, err := listPendingPRs(c, owner, repo, branch, false)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
+	renderBlock(f, "pendingPRs", &ReleaseNotes{Branch: branch, PendingPRs: prs})
 }
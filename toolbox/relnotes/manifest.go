@@ -0,0 +1,178 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	u "k8s.io/release/toolbox/util"
+)
+
+// Manifest lists the repositories released together, whose notes are aggregated into a single
+// document. Manifest files are YAML or JSON, for example:
+//
+//     title: Kubernetes v1.8.1
+//     repos:
+//     - repo: kubernetes/kubernetes
+//       range: v1.8.0..v1.8.1
+//       branch: release-1.8
+//     - repo: kubernetes/kubectl
+//       range: v0.1.0..v0.2.0
+type Manifest struct {
+	// Title is the displayed name of the release, defaults to the title of the first repository
+	Title string `json:"title,omitempty"`
+	// Repos are the released repositories, the first one being the main one
+	Repos []ManifestRepo `json:"repos"`
+}
+
+// ManifestRepo is a repository of a manifest, and the range of its release.
+type ManifestRepo struct {
+	// Repo is the Github repository, as owner/repo
+	Repo string `json:"repo"`
+	// Range is in the format of [[startTag..]endTag], see determineRange
+	Range string `json:"range,omitempty"`
+	// Branch defaults to master
+	Branch string `json:"branch,omitempty"`
+}
+
+// loadManifest reads and validates a YAML or JSON manifest file.
+func loadManifest(filename string) (*Manifest, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest file %s: %v", filename, err)
+	}
+	m := &Manifest{}
+	if err = yaml.Unmarshal(content, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest file %s: %v", filename, err)
+	}
+
+	if len(m.Repos) == 0 {
+		return nil, fmt.Errorf("manifest file %s lists no repositories", filename)
+	}
+	seen := make(map[string]bool)
+	for i, r := range m.Repos {
+		if parts := strings.Split(r.Repo, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid repository %q in manifest file %s, want owner/repo", r.Repo, filename)
+		}
+		if seen[r.Repo] {
+			return nil, fmt.Errorf("repository %s is listed twice in manifest file %s", r.Repo, filename)
+		}
+		seen[r.Repo] = true
+		if r.Branch == "" {
+			m.Repos[i].Branch = "master"
+		}
+	}
+	return m, nil
+}
+
+// gatherManifestNotes gathers the release information of all the repositories of input manifest
+// in parallel, and aggregates them into a single release note document.
+func gatherManifestNotes(g *u.GithubClient, m *Manifest) (*ReleaseNotes, error) {
+	var categories []noteCategory
+	if *categorize {
+		var err error
+		if categories, err = parseCategories(*categoryMap); err != nil {
+			return nil, err
+		}
+	}
+
+	infos := make([]*ReleaseInfo, len(m.Repos))
+	errs := make([]error, len(m.Repos))
+	var wg sync.WaitGroup
+	for i, r := range m.Repos {
+		wg.Add(1)
+		go func(i int, r ManifestRepo) {
+			defer wg.Done()
+			parts := strings.Split(r.Repo, "/")
+			infos[i], errs[i] = gatherReleaseInfo(g, parts[0], parts[1], r.Branch, r.Range)
+		}(i, r)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to gather %s release information: %v", m.Repos[i].Repo, err)
+		}
+	}
+	branchHead = infos[0].branchHead
+
+	return aggregateRepoNotes(m, infos, categories), nil
+}
+
+// aggregateRepoNotes builds the release note document of input manifest from the release
+// information of each repository: one section per repository, and the per repository and
+// total counts of notes. The notes are categorized if categories are given.
+func aggregateRepoNotes(m *Manifest, infos []*ReleaseInfo, categories []noteCategory) *ReleaseNotes {
+	first := infos[0]
+	notes := &ReleaseNotes{
+		Title:       m.Title,
+		Version:     first.releaseTag,
+		StartTag:    first.startTag,
+		Branch:      m.Repos[0].Branch,
+		GeneratedAt: time.Now(),
+		DocURL:      *documentURL,
+		ExampleURL:  fmt.Sprintf("%s%s/examples", *exampleURLPrefix, m.Repos[0].Branch),
+		Sections:    make([]NoteSection, 0),
+		Repos:       make([]RepoSummary, 0),
+		Totals:      &RepoSummary{Repo: "Total"},
+	}
+	if notes.Title == "" {
+		notes.Title = releaseTitle(first.releaseTag, m.Repos[0].Branch, false)
+	}
+
+	allAuthors := make(map[string]bool)
+	for i, info := range infos {
+		var sections []NoteSection
+		if categories != nil {
+			sections = categorizedRelease(info, categories)
+		} else {
+			sections = patchRelease(info)
+		}
+
+		summary := RepoSummary{Repo: m.Repos[i].Repo, StartTag: info.startTag, Version: info.releaseTag}
+		authors := make(map[string]bool)
+		repoSection := NoteSection{Title: m.Repos[i].Repo, Entries: make([]NoteEntry, 0), Subsections: make([]NoteSection, 0)}
+		for _, s := range sections {
+			if len(s.Entries) == 0 {
+				continue
+			}
+			for j := range s.Entries {
+				e := &s.Entries[j]
+				e.Repo = m.Repos[i].Repo
+				summary.Notes++
+				if e.ActionRequired {
+					summary.ActionRequired++
+				}
+				authors[e.Author] = true
+				allAuthors[e.Author] = true
+			}
+			repoSection.Subsections = append(repoSection.Subsections, s)
+		}
+		summary.Contributors = len(authors)
+
+		notes.Sections = append(notes.Sections, repoSection)
+		notes.Repos = append(notes.Repos, summary)
+		notes.Totals.Notes += summary.Notes
+		notes.Totals.ActionRequired += summary.ActionRequired
+		log.Printf("%s: %d release notes", summary.Repo, summary.Notes)
+	}
+	notes.Totals.Contributors = len(allAuthors)
+	return notes
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/github"
+)
+
+func TestLoadManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "relnotes-manifest")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	want := &Manifest{
+		Title: "Kubernetes v1.8.1",
+		Repos: []ManifestRepo{
+			{"kubernetes/kubernetes", "v1.8.0..v1.8.1", "release-1.8"},
+			{"kubernetes/kubectl", "v0.1.0..v0.2.0", "master"},
+		},
+	}
+	tables := []struct {
+		name    string
+		content string
+	}{
+		{"manifest.yaml", "title: Kubernetes v1.8.1\nrepos:\n- repo: kubernetes/kubernetes\n  range: v1.8.0..v1.8.1\n  branch: release-1.8\n- repo: kubernetes/kubectl\n  range: v0.1.0..v0.2.0\n"},
+		{"manifest.json", `{"title": "Kubernetes v1.8.1", "repos": [{"repo": "kubernetes/kubernetes", "range": "v1.8.0..v1.8.1", "branch": "release-1.8"}, {"repo": "kubernetes/kubectl", "range": "v0.1.0..v0.2.0"}]}`},
+	}
+
+	for _, table := range tables {
+		file := filepath.Join(dir, table.name)
+		if err := ioutil.WriteFile(file, []byte(table.content), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		m, err := loadManifest(file)
+		if err != nil {
+			t.Errorf("%s: Unexpected error: %v", table.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(m, want) {
+			t.Errorf("%s: Manifest was incorrect, want: %+v, got: %+v", table.name, want, m)
+		}
+	}
+
+	for _, content := range []string{
+		"repos: []\n",
+		"repos:\n- repo: kubectl\n",
+		"repos:\n- repo: kubernetes/kubectl\n- repo: kubernetes/kubectl\n",
+	} {
+		file := filepath.Join(dir, "bad.yaml")
+		ioutil.WriteFile(file, []byte(content), 0644)
+		if _, err := loadManifest(file); err == nil {
+			t.Errorf("%q: Expected error", content)
+		}
+	}
+}
+
+// newTestManifest creates a manifest of kubernetes/kubernetes (see newTestReleaseInfo) and
+// kubernetes/kubectl, with its release information.
+func newTestManifest() (*Manifest, []*ReleaseInfo) {
+	m := &Manifest{
+		Title: "Kubernetes v1.8.1",
+		Repos: []ManifestRepo{
+			{"kubernetes/kubernetes", "v1.8.0..v1.8.1", "release-1.8"},
+			{"kubernetes/kubectl", "v0.1.0..v0.2.0", "master"},
+		},
+	}
+	kubectl := &ReleaseInfo{
+		startTag:   "v0.1.0",
+		releaseTag: "v0.2.0",
+		prMap: map[int]*github.Issue{
+			12: newTestPR(12, "liggitt", "Add kubectl tree", "```release-note\nAdds `kubectl tree`.\n```", "release-note"),
+		},
+		releasePRs: []int{12},
+	}
+	return m, []*ReleaseInfo{newTestReleaseInfo(), kubectl}
+}
+
+func TestAggregateRepoNotes(t *testing.T) {
+	m, infos := newTestManifest()
+	notes := aggregateRepoNotes(m, infos, nil)
+
+	if notes.Title != "Kubernetes v1.8.1" || notes.Version != "v1.8.1" || notes.StartTag != "v1.8.0" {
+		t.Errorf("Release was incorrect, got: %v %v..%v", notes.Title, notes.StartTag, notes.Version)
+	}
+	wantRepos := []RepoSummary{
+		{"kubernetes/kubernetes", "v1.8.0", "v1.8.1", 3, 1, 2},
+		{"kubernetes/kubectl", "v0.1.0", "v0.2.0", 1, 0, 1},
+	}
+	if !reflect.DeepEqual(notes.Repos, wantRepos) {
+		t.Errorf("Repository summaries were incorrect, want: %+v, got: %+v", wantRepos, notes.Repos)
+	}
+	wantTotals := &RepoSummary{Repo: "Total", Notes: 4, ActionRequired: 1, Contributors: 2}
+	if !reflect.DeepEqual(notes.Totals, wantTotals) {
+		t.Errorf("Totals were incorrect, want: %+v, got: %+v", wantTotals, notes.Totals)
+	}
+
+	var titles []string
+	for _, s := range notes.Sections {
+		titles = append(titles, s.Title)
+		for _, sub := range s.Subsections {
+			titles = append(titles, s.Title+"/"+sub.Title)
+			for _, e := range sub.Entries {
+				if e.Repo != s.Title {
+					t.Errorf("#%d: Repository was incorrect, want: %v, got: %v", e.Number, s.Title, e.Repo)
+				}
+			}
+		}
+	}
+	wantTitles := []string{
+		"kubernetes/kubernetes", "kubernetes/kubernetes/Action Required", "kubernetes/kubernetes/Other notable changes",
+		"kubernetes/kubectl", "kubernetes/kubectl/Other notable changes",
+	}
+	if !reflect.DeepEqual(titles, wantTitles) {
+		t.Errorf("Sections were incorrect, want: %v, got: %v", wantTitles, titles)
+	}
+}
+
+func TestWriteMarkdownManifest(t *testing.T) {
+	m, infos := newTestManifest()
+	infos[1].releasePRs = nil
+	notes := aggregateRepoNotes(m, infos, nil)
+
+	var b bytes.Buffer
+	if err := writeMarkdown(&b, notes); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := "## Totals\n\n" +
+		"repository | range | release notes | action required | contributors\n" +
+		"---------- | ----- | ------------- | --------------- | ------------\n" +
+		"kubernetes/kubernetes | v1.8.0..v1.8.1 | 3 | 1 | 2\n" +
+		"kubernetes/kubectl | v0.1.0..v0.2.0 | 0 | 0 | 0\n" +
+		"**Total** | | 3 | 1 | 2\n\n" +
+		"## kubernetes/kubernetes\n\n" +
+		"### Action Required\n\n* The --foo flag was removed. (kubernetes/kubernetes#52602, @thockin)\n\n" +
+		"### Other notable changes\n\n" +
+		"* Fixes a performance issue when deleting pods. (kubernetes/kubernetes#53233, @liggitt)\n" +
+		"* Change default --cert-dir for kubelet (kubernetes/kubernetes#53317, @liggitt)\n\n" +
+		"## kubernetes/kubectl\n\n**No notable changes for this release**\n\n"
+	if !strings.HasSuffix(b.String(), want) {
+		t.Errorf("Markdown was incorrect, want suffix:\n%s\ngot:\n%s", want, b.String())
+	}
+	if strings.Contains(b.String(), "Changelog since") {
+		t.Errorf("Unexpected single repository changelog:\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := writeText(&b, notes); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, s := range []string{
+		"kubernetes/kubectl v0.1.0..v0.2.0: 0 release notes, 0 action required, 0 contributors\n",
+		"Total: 3 release notes, 1 action required, 2 contributors\n",
+		"kubernetes/kubernetes:\n  Action Required:\n  - The --foo flag was removed. (kubernetes/kubernetes#52602, @thockin)\n",
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("Text missing %q:\n%s", s, b.String())
+		}
+	}
+}
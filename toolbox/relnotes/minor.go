@@ -0,0 +1,117 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	u "k8s.io/release/toolbox/util"
+)
+
+var (
+	// dotzeroVersion matches minor release versions, e.g. "v1.8.0".
+	dotzeroVersion = regexp.MustCompile("^v([0-9]+)\\.([0-9]+)\\.0$")
+	// draftReference matches the PR references of a release notes draft, e.g. "#53233" or
+	// "https://github.com/kubernetes/kubernetes/pull/53233".
+	draftReference = regexp.MustCompile("(?:#|/pull/)([0-9]+)\\b")
+)
+
+// minorRange returns the range of notes aggregated for a minor release: from the previous minor
+// release to the new one, e.g. "v1.7.0..v1.8.0" for "v1.8.0" or "v1.8.0-rc.1..v1.8.0". Ranges
+// which don't end with a minor release are returned as is. The tags of the range are on different
+// branches, so the range is resolved by reachability (see rangePRs): it starts from the merge-base
+// of the two release branches, i.e. vX.Y.0-alpha.0, rather than from the date of the previous
+// minor release.
+func minorRange(branchRange string) (string, error) {
+	releaseTag := branchRange
+	if i := strings.Index(branchRange, ".."); i != -1 {
+		releaseTag = branchRange[i+2:]
+	}
+	if !u.IsVer(releaseTag, verDotzero) {
+		return branchRange, nil
+	}
+
+	startTag, err := previousMinor(releaseTag)
+	if err != nil {
+		return "", err
+	}
+	return startTag + ".." + releaseTag, nil
+}
+
+// checkMinorRange makes sure the range of input release information covers the whole minor
+// release if it is one, e.g. v1.7.0..v1.8.0 for v1.8.0, as needed for the deprecations and
+// enhancements of the release.
+func checkMinorRange(info *ReleaseInfo) error {
+	if !u.IsVer(info.releaseTag, verDotzero) {
+		return nil
+	}
+	startTag, err := previousMinor(info.releaseTag)
+	if err != nil {
+		return err
+	}
+	if info.startTag != startTag {
+		return fmt.Errorf("range %s..%s doesn't cover minor release %s, use %s..%s", info.startTag, info.releaseTag, info.releaseTag, startTag, info.releaseTag)
+	}
+	return nil
+}
+
+// previousMinor returns the minor release preceding input one, e.g. "v1.7.0" for "v1.8.0".
+func previousMinor(release string) (string, error) {
+	v := dotzeroVersion.FindStringSubmatch(release)
+	if v == nil {
+		return "", fmt.Errorf("%s is not a minor release", release)
+	}
+	minor, err := strconv.Atoi(v[2])
+	if err != nil {
+		return "", err
+	}
+	if minor == 0 {
+		return "", fmt.Errorf("no minor release precedes %s", release)
+	}
+	return fmt.Sprintf("v%s.%d.0", v[1], minor-1), nil
+}
+
+// draftPRs returns the PRs referenced by input release notes draft, whose notes were already
+// hand-written there.
+func draftPRs(draft string) map[int]bool {
+	prs := make(map[int]bool)
+	for _, m := range draftReference.FindAllStringSubmatch(draft, -1) {
+		if pr, err := strconv.Atoi(m[1]); err == nil {
+			prs[pr] = true
+		}
+	}
+	return prs
+}
+
+// dropPRs removes the entries of input PRs from sections, and returns the number of removed
+// entries. Sections left empty are kept so the section order stays stable.
+func dropPRs(sections []NoteSection, prs map[int]bool) ([]NoteSection, int) {
+	dropped := 0
+	for i, s := range sections {
+		entries := make([]NoteEntry, 0)
+		for _, e := range s.Entries {
+			if prs[e.Number] {
+				dropped++
+				continue
+			}
+			entries = append(entries, e)
+		}
+		sections[i].Entries = entries
+	}
+	return sections, dropped
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMinorRange(t *testing.T) {
+	tables := []struct {
+		branchRange string
+		want        string
+		err         bool
+	}{
+		{"v1.8.0", "v1.7.0..v1.8.0", false},
+		{"v1.8.0-rc.1..v1.8.0", "v1.7.0..v1.8.0", false},
+		{"v1.10.0", "v1.9.0..v1.10.0", false},
+		{"v1.8.0..v1.8.1", "v1.8.0..v1.8.1", false},
+		{"v1.8.0-rc.1", "v1.8.0-rc.1", false},
+		{"", "", false},
+		{"v1.0.0", "", true},
+	}
+
+	for _, table := range tables {
+		got, err := minorRange(table.branchRange)
+		if (err != nil) != table.err || got != table.want {
+			t.Errorf("%q: Range was incorrect, want: %q (error: %v), got: %q (%v)", table.branchRange, table.want, table.err, got, err)
+		}
+	}
+}
+
+func TestCheckMinorRange(t *testing.T) {
+	tables := []struct {
+		startTag, releaseTag string
+		valid                bool
+	}{
+		{"v1.7.0", "v1.8.0", true},
+		{"v1.8.0-rc.1", "v1.8.0", false},
+		{"v1.8.0", "v1.8.1", true},
+		{"v1.8.0-rc.1", "v1.8.0-rc.2", true},
+	}
+
+	for _, table := range tables {
+		err := checkMinorRange(&ReleaseInfo{startTag: table.startTag, releaseTag: table.releaseTag})
+		if (err == nil) != table.valid {
+			t.Errorf("%s..%s: Validity check failed, want: %v, got error: %v", table.startTag, table.releaseTag, table.valid, err)
+		}
+	}
+}
+
+func TestDraftPRs(t *testing.T) {
+	draft := "## Major Themes\n\n" +
+		"* Workloads API goes beta (#53233, [#52602](https://github.com/kubernetes/kubernetes/pull/52602))\n" +
+		"* See https://github.com/kubernetes/features/issues/42 and [v1.8.0](#v180)\n"
+
+	want := map[int]bool{53233: true, 52602: true}
+	if got := draftPRs(draft); !reflect.DeepEqual(got, want) {
+		t.Errorf("Draft PRs were incorrect, want: %v, got: %v", want, got)
+	}
+}
+
+func TestDropPRs(t *testing.T) {
+	sections := patchRelease(newTestReleaseInfo())
+	sections, dropped := dropPRs(sections, map[int]bool{52602: true, 53317: true, 1: true})
+
+	if dropped != 2 {
+		t.Errorf("Dropped entries were incorrect, want: %v, got: %v", 2, dropped)
+	}
+	var prs []int
+	for _, s := range sections {
+		for _, e := range s.Entries {
+			prs = append(prs, e.Number)
+		}
+	}
+	if want := []int{53233}; !reflect.DeepEqual(prs, want) {
+		t.Errorf("Remaining PRs were incorrect, want: %v, got: %v", want, prs)
+	}
+	if len(sections) != 2 {
+		t.Errorf("Number of sections was incorrect, want: %v, got: %v", 2, len(sections))
+	}
+}
@@ -0,0 +1,383 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	u "k8s.io/release/toolbox/util"
+)
+
+// ReleaseNotes is the document model of a release note. It is gathered once and then rendered
+// into the requested output format.
+type ReleaseNotes struct {
+	// Title is the displayed name of the release, e.g. "v1.8.2" or "Branch release-1.8"
+	Title string `json:"title"`
+	// Version is the release tag, or the branch HEAD commit for unreleased notes
+	Version     string    `json:"version"`
+	StartTag    string    `json:"startTag"`
+	Branch      string    `json:"branch"`
+	Preview     bool      `json:"preview,omitempty"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	DocURL      string    `json:"docURL"`
+	ExampleURL  string    `json:"exampleURL"`
+
+	// Minor is true for the "full" vX.Y.0 layout, which is made of the draft and the list of
+	// previous releases in series instead of the per-PR sections. With --aggregate-minor, the
+	// sections contain the notes of all the PRs since the previous minor release as well.
+	Minor            bool     `json:"minor"`
+	Draft            string   `json:"draft,omitempty"`
+	PreviousReleases []string `json:"previousReleases,omitempty"`
+	// KnownIssues and Deprecations are gathered with --known-issues. The ones the draft doesn't
+	// reference yet are merged into its sections as well
+	KnownIssues  []KnownIssue `json:"knownIssues,omitempty"`
+	Deprecations []NoteEntry  `json:"deprecations,omitempty"`
+	// Enhancements are the enhancements of the release milestone by stage (--enhancements)
+	Enhancements []EnhancementStage `json:"enhancements,omitempty"`
+
+	Sections     []NoteSection   `json:"sections"`
+	Downloads    []DownloadTable `json:"downloads,omitempty"`
+	APIChanges   *APIChanges     `json:"apiChanges,omitempty"`
+	Dependencies *DepDiff        `json:"dependencies,omitempty"`
+	PendingPRs   []PendingPR     `json:"pendingPRs,omitempty"`
+
+	// Repos summarizes the notes of each repository of a --manifest release, in which case there
+	// is one section per repository, whose subsections are the usual sections
+	Repos  []RepoSummary `json:"repos,omitempty"`
+	Totals *RepoSummary  `json:"totals,omitempty"`
+}
+
+// NoteSection is a titled list of release note entries.
+type NoteSection struct {
+	Title   string      `json:"title"`
+	Entries []NoteEntry `json:"entries"`
+	// Subsections contains the entries grouped by SIG, in which case Entries is empty
+	Subsections []NoteSection `json:"subsections,omitempty"`
+}
+
+// NoteEntry is the release note of a single PR.
+type NoteEntry struct {
+	Number         int      `json:"number"`
+	Author         string   `json:"author"`
+	Text           string   `json:"text"`
+	Labels         []string `json:"labels"`
+	Kind           string   `json:"kind,omitempty"`
+	SIG            string   `json:"sig,omitempty"`
+	ActionRequired bool     `json:"actionRequired,omitempty"`
+	// Repo is the owner/repo of the PR, only set for --manifest releases
+	Repo string `json:"repo,omitempty"`
+}
+
+// RepoSummary counts the release notes of a repository of a --manifest release.
+type RepoSummary struct {
+	Repo           string `json:"repo"`
+	StartTag       string `json:"startTag,omitempty"`
+	Version        string `json:"version,omitempty"`
+	Notes          int    `json:"notes"`
+	ActionRequired int    `json:"actionRequired"`
+	Contributors   int    `json:"contributors"`
+}
+
+// DownloadTable is a table of release artifacts under an optional heading.
+type DownloadTable struct {
+	Heading string         `json:"heading,omitempty"`
+	Files   []DownloadFile `json:"files"`
+}
+
+// DownloadFile is a downloadable release artifact.
+type DownloadFile struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+	SHA512 string `json:"sha512"`
+	// Signature and Certificate are the URLs of the detached signature and certificate of the
+	// artifact, if any
+	Signature   string `json:"signature,omitempty"`
+	Certificate string `json:"certificate,omitempty"`
+}
+
+// PendingPR is an open PR against the release branch.
+type PendingPR struct {
+	Number    int       `json:"number"`
+	Milestone string    `json:"milestone"`
+	Author    string    `json:"author"`
+	Updated   time.Time `json:"updated"`
+	Title     string    `json:"title"`
+	Labels    []string  `json:"labels,omitempty"`
+	// Status is the review and CI status of the PR, only gathered by the preview subcommand
+	Status *u.PRStatus `json:"status,omitempty"`
+}
+
+// gatherReleaseNotes builds the release note document for input release information.
+func gatherReleaseNotes(g *u.GithubClient, info *ReleaseInfo) (*ReleaseNotes, error) {
+	notes := &ReleaseNotes{
+		Title:       releaseTitle(info.releaseTag, *branch, *preview),
+		Version:     info.releaseTag,
+		StartTag:    info.startTag,
+		Branch:      *branch,
+		Preview:     *preview,
+		GeneratedAt: time.Now(),
+		DocURL:      *documentURL,
+		ExampleURL:  fmt.Sprintf("%s%s/examples", *exampleURLPrefix, *branch),
+		Sections:    make([]NoteSection, 0),
+	}
+
+	// Bootstrap notes for minor (new branch) releases
+	if *full || u.IsVer(info.releaseTag, verDotzero) {
+		draftURL := fmt.Sprintf("%s%s/features/master/%s/release-notes-draft.md", u.GithubRawURL, *owner, *branch)
+		changelogURL := fmt.Sprintf("%s%s/%s/master/CHANGELOG%s.md", u.GithubRawURL, *owner, *repo, branchVerSuffix)
+		notes.Minor = true
+		notes.Draft, notes.PreviousReleases = minorRelease(info.releaseTag, draftURL, changelogURL)
+		listed := draftPRs(notes.Draft)
+		if *knownIssues {
+			if err := gatherKnownIssues(g, info, notes); err != nil {
+				return nil, err
+			}
+			for _, e := range notes.Deprecations {
+				listed[e.Number] = true
+			}
+		}
+		if *enhancements {
+			if err := gatherEnhancements(g, info, notes); err != nil {
+				return nil, err
+			}
+		}
+		if *aggregateMinor {
+			categories, err := parseCategories(*categoryMap)
+			if err != nil {
+				return nil, err
+			}
+			var merged int
+			notes.Sections, merged = dropPRs(categorizedRelease(info, categories), listed)
+			log.Printf("%d release notes are already in the draft or deprecations, skipping them", merged)
+		}
+	} else if *categorize {
+		categories, err := parseCategories(*categoryMap)
+		if err != nil {
+			return nil, err
+		}
+		notes.Sections = categorizedRelease(info, categories)
+	} else {
+		notes.Sections = patchRelease(info)
+	}
+	if *overridesFile != "" {
+		overrides, err := loadOverrides(*overridesFile)
+		if err != nil {
+			return nil, err
+		}
+		var unused []int
+		notes.Sections, unused = applyOverrides(notes.Sections, overrides)
+		for _, pr := range unused {
+			log.Printf("WARNING: unused override for PR #%d: no such release note", pr)
+		}
+	}
+	if *groupBySIG {
+		for i := range notes.Sections {
+			notes.Sections[i].Subsections = sigSubsections(notes.Sections[i].Entries)
+			notes.Sections[i].Entries = make([]NoteEntry, 0)
+		}
+	}
+
+	var err error
+	if *releaseTars != "" || *downloadManifest != "" {
+		notes.Downloads, err = getDownloads(info.releaseTag, *releaseTars, *downloadManifest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create downloads table: %v", err)
+		}
+	}
+
+	fetch := newFileFetcher(g, *owner, *repo, *repoDir)
+	if *apiChanges {
+		log.Print("Gathering API changes...")
+		notes.APIChanges, err = getAPIChanges(fetch, info.startTag, info.releaseTag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to gather API changes: %v", err)
+		}
+	}
+	if *dependencies {
+		log.Print("Gathering dependency changes...")
+		notes.Dependencies, err = getDepDiff(fetch, info.startTag, info.releaseTag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to gather dependency changes: %v", err)
+		}
+	}
+
+	if *preview {
+		// If in preview mode, get the pending PRs
+		notes.PendingPRs, err = listPendingPRs(g, *owner, *repo, *branch, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pending PRs: %v", err)
+		}
+	}
+	return notes, nil
+}
+
+// unreleased checks if input release tag is actually the head of the branch, for the notes of
+// the changes since the last release.
+func unreleased(releaseTag string) bool {
+	return releaseTag == "HEAD" || releaseTag == branchHead
+}
+
+// releaseTitle determines the displayed name of a release.
+func releaseTitle(releaseTag, branch string, preview bool) string {
+	var title string
+	if preview {
+		title = "Branch "
+	}
+
+	if unreleased(releaseTag) {
+		title += branch
+	} else {
+		title += releaseTag
+	}
+	return title
+}
+
+// patchRelease builds the sections of a patch (vX.Y.Z) release from all the related changes.
+func patchRelease(info *ReleaseInfo) []NoteSection {
+	actionRequired := NoteSection{Title: actionRequiredTitle, Entries: make([]NoteEntry, 0)}
+	for _, pr := range info.releaseActionRequiredPRs {
+		actionRequired.Entries = append(actionRequired.Entries, newNoteEntry(info.prMap[pr], true))
+	}
+
+	other := NoteSection{Title: "Other notable changes", Entries: make([]NoteEntry, 0)}
+	for _, pr := range info.releasePRs {
+		other.Entries = append(other.Entries, newNoteEntry(info.prMap[pr], false))
+	}
+
+	return []NoteSection{actionRequired, other}
+}
+
+// noteCategory maps a label to the title of the section its release notes go in.
+type noteCategory struct {
+	label string
+	title string
+}
+
+// parseCategories parses a comma-separated list of label=title category mappings, e.g.
+// "kind/feature=New Features,kind/bug=Bug Fixes". Several labels can map to the same title.
+func parseCategories(s string) ([]noteCategory, error) {
+	categories := make([]noteCategory, 0)
+	for _, c := range strings.Split(s, ",") {
+		if strings.TrimSpace(c) == "" {
+			continue
+		}
+		kv := strings.SplitN(c, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" || strings.TrimSpace(kv[1]) == "" {
+			return nil, fmt.Errorf("invalid category %q, want label=title", c)
+		}
+		categories = append(categories, noteCategory{strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])})
+	}
+	return categories, nil
+}
+
+// categorizedRelease builds the sections of a patch (vX.Y.Z) release grouped by category. Action
+// required PRs keep their own section first. Other PRs go in the section of the first category
+// (in mapping order) matching one of their labels, or in the "Uncategorized" section.
+func categorizedRelease(info *ReleaseInfo, categories []noteCategory) []NoteSection {
+	sections := []NoteSection{{Title: actionRequiredTitle, Entries: make([]NoteEntry, 0)}}
+	for _, pr := range info.releaseActionRequiredPRs {
+		sections[0].Entries = append(sections[0].Entries, newNoteEntry(info.prMap[pr], true))
+	}
+
+	index := make(map[string]int)
+	for _, c := range categories {
+		if _, ok := index[c.title]; !ok {
+			index[c.title] = len(sections)
+			sections = append(sections, NoteSection{Title: c.title, Entries: make([]NoteEntry, 0)})
+		}
+	}
+	uncategorized := NoteSection{Title: uncategorizedTitle, Entries: make([]NoteEntry, 0)}
+
+	for _, pr := range info.releasePRs {
+		e := newNoteEntry(info.prMap[pr], false)
+		i, ok := categoryIndex(e.Labels, categories, index)
+		if !ok {
+			uncategorized.Entries = append(uncategorized.Entries, e)
+			continue
+		}
+		sections[i].Entries = append(sections[i].Entries, e)
+	}
+	return append(sections, uncategorized)
+}
+
+// categoryIndex returns the section index of the first category matching input labels.
+func categoryIndex(labels []string, categories []noteCategory, index map[string]int) (int, bool) {
+	for _, c := range categories {
+		for _, l := range labels {
+			if l == c.label {
+				return index[c.title], true
+			}
+		}
+	}
+	return 0, false
+}
+
+// sigSubsections groups input entries into per-SIG subsections, sorted by SIG name. Entries
+// without SIG label go in a last "No SIG" subsection.
+func sigSubsections(entries []NoteEntry) []NoteSection {
+	bySIG := make(map[string][]NoteEntry)
+	for _, e := range entries {
+		bySIG[e.SIG] = append(bySIG[e.SIG], e)
+	}
+
+	sigs := make([]string, 0, len(bySIG))
+	for sig := range bySIG {
+		if sig != "" {
+			sigs = append(sigs, sig)
+		}
+	}
+	sort.Strings(sigs)
+
+	subsections := make([]NoteSection, 0, len(bySIG))
+	for _, sig := range sigs {
+		subsections = append(subsections, NoteSection{Title: sigTitle(sig), Entries: bySIG[sig]})
+	}
+	if noSIG, ok := bySIG[""]; ok {
+		subsections = append(subsections, NoteSection{Title: "No SIG", Entries: noSIG})
+	}
+	return subsections
+}
+
+// sigTitle returns the displayed name of a SIG, e.g. "api-machinery" becomes "SIG Api Machinery".
+func sigTitle(sig string) string {
+	return "SIG " + strings.Title(strings.Replace(sig, "-", " ", -1))
+}
+
+// newNoteEntry creates the release note entry of input PR.
+func newNoteEntry(pr *github.Issue, actionRequired bool) NoteEntry {
+	e := NoteEntry{
+		Number:         *pr.Number,
+		Author:         *pr.User.Login,
+		Text:           extractReleaseNoteFromPR(pr),
+		Labels:         make([]string, 0),
+		ActionRequired: actionRequired,
+	}
+	for _, l := range pr.Labels {
+		e.Labels = append(e.Labels, *l.Name)
+		if strings.HasPrefix(*l.Name, "kind/") && e.Kind == "" {
+			e.Kind = strings.TrimPrefix(*l.Name, "kind/")
+		}
+		if strings.HasPrefix(*l.Name, "sig/") && e.SIG == "" {
+			e.SIG = strings.TrimPrefix(*l.Name, "sig/")
+		}
+	}
+	return e
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/github"
+)
+
+// newTestPR creates a PR with input number, author, body and labels for tests.
+func newTestPR(number int, author, title, body string, labels ...string) *github.Issue {
+	pr := &github.Issue{
+		Number: github.Int(number),
+		Title:  github.String(title),
+		Body:   github.String(body),
+		User:   &github.User{Login: github.String(author)},
+	}
+	for _, l := range labels {
+		pr.Labels = append(pr.Labels, github.Label{Name: github.String(l)})
+	}
+	return pr
+}
+
+// newTestReleaseInfo creates release information for a v1.8.0..v1.8.1 patch release with one
+// action required PR and two other PRs.
+func newTestReleaseInfo() *ReleaseInfo {
+	return &ReleaseInfo{
+		startTag:   "v1.8.0",
+		releaseTag: "v1.8.1",
+		prMap: map[int]*github.Issue{
+			53233: newTestPR(53233, "liggitt", "Remove containers of deleted pods",
+				"```release-note\r\nFixes a performance issue when deleting pods.\r\n```",
+				"release-note", "kind/bug", "sig/node"),
+			53317: newTestPR(53317, "liggitt", "Change default --cert-dir for kubelet", "",
+				"release-note", "sig/node", "sig/auth"),
+			52602: newTestPR(52602, "thockin", "Drop support for the foo flag",
+				"```release-note\r\nThe --foo flag was removed.\r\n```",
+				"release-note-action-required", "kind/cleanup"),
+		},
+		releasePRs:               []int{53233, 53317},
+		releaseActionRequiredPRs: []int{52602},
+	}
+}
+
+func TestPatchRelease(t *testing.T) {
+	sections := patchRelease(newTestReleaseInfo())
+	if len(sections) != 2 {
+		t.Fatalf("Number of sections was incorrect, want: 2, got: %d", len(sections))
+	}
+
+	tables := []struct {
+		section int
+		entry   int
+		want    NoteEntry
+	}{
+		{0, 0, NoteEntry{Number: 52602, Author: "thockin", Text: "The --foo flag was removed.", Kind: "cleanup", ActionRequired: true}},
+		{1, 0, NoteEntry{Number: 53233, Author: "liggitt", Text: "Fixes a performance issue when deleting pods.", Kind: "bug", SIG: "node"}},
+		{1, 1, NoteEntry{Number: 53317, Author: "liggitt", Text: "Change default --cert-dir for kubelet", SIG: "node"}},
+	}
+
+	for _, table := range tables {
+		got := sections[table.section].Entries[table.entry]
+		got.Labels = nil
+		if got.Number != table.want.Number || got.Author != table.want.Author || got.Text != table.want.Text ||
+			got.Kind != table.want.Kind || got.SIG != table.want.SIG || got.ActionRequired != table.want.ActionRequired {
+			t.Errorf("Entry was incorrect, want: %+v, got: %+v", table.want, got)
+		}
+	}
+}
+
+func TestReleaseTitle(t *testing.T) {
+	branchHead = "5adaee21de0c5ed1286a00468e09d866605f85f4"
+	tables := []struct {
+		releaseTag string
+		preview    bool
+		title      string
+	}{
+		{"v1.8.1", false, "v1.8.1"},
+		{"HEAD", false, "release-1.8"},
+		{branchHead, true, "Branch release-1.8"},
+	}
+
+	for _, table := range tables {
+		if title := releaseTitle(table.releaseTag, "release-1.8", table.preview); title != table.title {
+			t.Errorf("%v: Title was incorrect, want: %s, got: %s", table.releaseTag, table.title, title)
+		}
+	}
+}
+
+func TestParseCategories(t *testing.T) {
+	tables := []struct {
+		s          string
+		categories []noteCategory
+		err        bool
+	}{
+		{"kind/feature=New Features, kind/bug = Bug Fixes,", []noteCategory{{"kind/feature", "New Features"}, {"kind/bug", "Bug Fixes"}}, false},
+		{"", []noteCategory{}, false},
+		{"kind/feature", nil, true},
+		{"kind/feature=", nil, true},
+	}
+
+	for _, table := range tables {
+		categories, err := parseCategories(table.s)
+		if (err != nil) != table.err {
+			t.Errorf("%q: Error was incorrect, want error: %v, got: %v", table.s, table.err, err)
+			continue
+		}
+		if !reflect.DeepEqual(categories, table.categories) && !table.err {
+			t.Errorf("%q: Categories were incorrect, want: %v, got: %v", table.s, table.categories, categories)
+		}
+	}
+}
+
+func TestCategorizedRelease(t *testing.T) {
+	info := newTestReleaseInfo()
+	info.prMap[53412] = newTestPR(53412, "deads2k", "Add the --bar flag", "", "release-note", "kind/feature", "kind/bug", "sig/api-machinery")
+	info.releasePRs = append(info.releasePRs, 53412)
+	categories, _ := parseCategories(defaultCategories)
+
+	sections := categorizedRelease(info, categories)
+	want := map[string][]int{
+		"Action Required": {52602},
+		"New Features":    {53412},
+		"Bug Fixes":       {53233},
+		"Deprecations":    nil,
+		"API Changes":     nil,
+		"Cleanups":        nil,
+		"Uncategorized":   {53317},
+	}
+	if len(sections) != len(want) {
+		t.Fatalf("Number of sections was incorrect, want: %d, got: %d", len(want), len(sections))
+	}
+	for _, s := range sections {
+		var got []int
+		for _, e := range s.Entries {
+			got = append(got, e.Number)
+		}
+		if !reflect.DeepEqual(got, want[s.Title]) {
+			t.Errorf("%s: PRs were incorrect, want: %v, got: %v", s.Title, want[s.Title], got)
+		}
+	}
+	if sections[0].Title != "Action Required" || sections[len(sections)-1].Title != "Uncategorized" {
+		t.Errorf("Section order was incorrect, got: %s ... %s", sections[0].Title, sections[len(sections)-1].Title)
+	}
+}
+
+func TestSIGSubsections(t *testing.T) {
+	entries := []NoteEntry{
+		{Number: 1, SIG: "node"},
+		{Number: 2},
+		{Number: 3, SIG: "api-machinery"},
+		{Number: 4, SIG: "node"},
+	}
+	want := []struct {
+		title   string
+		numbers []int
+	}{
+		{"SIG Api Machinery", []int{3}},
+		{"SIG Node", []int{1, 4}},
+		{"No SIG", []int{2}},
+	}
+
+	subsections := sigSubsections(entries)
+	if len(subsections) != len(want) {
+		t.Fatalf("Number of subsections was incorrect, want: %d, got: %d", len(want), len(subsections))
+	}
+	for i, s := range subsections {
+		var got []int
+		for _, e := range s.Entries {
+			got = append(got, e.Number)
+		}
+		if s.Title != want[i].title || !reflect.DeepEqual(got, want[i].numbers) {
+			t.Errorf("Subsection was incorrect, want: %s %v, got: %s %v", want[i].title, want[i].numbers, s.Title, got)
+		}
+	}
+}
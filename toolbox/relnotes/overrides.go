@@ -0,0 +1,136 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/ghodss/yaml"
+)
+
+// NoteOverride is a curated edit of the release note of a PR. Overrides files are YAML or JSON
+// maps of PR numbers to overrides, for example:
+//
+//     53233:
+//       text: Fixes a performance issue when deleting thousands of pods.
+//       section: Bug Fixes
+//     52602:
+//       actionRequired: true
+//     53317:
+//       drop: true
+type NoteOverride struct {
+	// Text replaces the release note text
+	Text string `json:"text,omitempty"`
+	// Section moves the release note to the section with this title, which is created if missing
+	Section string `json:"section,omitempty"`
+	// ActionRequired marks the release note as action required, and moves it to the action
+	// required section unless Section is set
+	ActionRequired bool `json:"actionRequired,omitempty"`
+	// Drop removes the release note
+	Drop bool `json:"drop,omitempty"`
+}
+
+// loadOverrides reads the PR-indexed release note overrides in input YAML or JSON file.
+func loadOverrides(filename string) (map[int]NoteOverride, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read overrides file %s: %v", filename, err)
+	}
+	overrides := make(map[int]NoteOverride)
+	if err = yaml.Unmarshal(content, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse overrides file %s: %v", filename, err)
+	}
+	return overrides, nil
+}
+
+// applyOverrides applies input overrides to the release note sections, and returns the updated
+// sections and the sorted PR numbers of the overrides that didn't match any release note.
+func applyOverrides(sections []NoteSection, overrides map[int]NoteOverride) ([]NoteSection, []int) {
+	used := make(map[int]bool)
+	type move struct {
+		section string
+		entry   NoteEntry
+	}
+	var moves []move
+
+	for i := range sections {
+		kept := make([]NoteEntry, 0, len(sections[i].Entries))
+		for _, e := range sections[i].Entries {
+			o, ok := overrides[e.Number]
+			if !ok {
+				kept = append(kept, e)
+				continue
+			}
+			used[e.Number] = true
+			if o.Drop {
+				continue
+			}
+			if o.Text != "" {
+				e.Text = o.Text
+			}
+			section := sections[i].Title
+			if o.ActionRequired {
+				e.ActionRequired = true
+				section = actionRequiredTitle
+			}
+			if o.Section != "" {
+				section = o.Section
+			}
+			if section == sections[i].Title {
+				kept = append(kept, e)
+			} else {
+				moves = append(moves, move{section, e})
+			}
+		}
+		sections[i].Entries = kept
+	}
+
+	for _, m := range moves {
+		i := sectionIndex(sections, m.section)
+		if i == -1 {
+			s := NoteSection{Title: m.section, Entries: make([]NoteEntry, 0)}
+			if m.section == actionRequiredTitle {
+				// The action required section always comes first
+				sections = append([]NoteSection{s}, sections...)
+				i = 0
+			} else {
+				sections = append(sections, s)
+				i = len(sections) - 1
+			}
+		}
+		sections[i].Entries = append(sections[i].Entries, m.entry)
+	}
+
+	unused := make([]int, 0)
+	for pr := range overrides {
+		if !used[pr] {
+			unused = append(unused, pr)
+		}
+	}
+	sort.Ints(unused)
+	return sections, unused
+}
+
+// sectionIndex returns the index of the section with input title, or -1 if there is none.
+func sectionIndex(sections []NoteSection, title string) int {
+	for i, s := range sections {
+		if s.Title == title {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadOverrides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "relnotes-overrides")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	want := map[int]NoteOverride{
+		53233: {Text: "Fixes pods.", Section: "Bug Fixes"},
+		52602: {ActionRequired: true},
+		53317: {Drop: true},
+	}
+	tables := []struct {
+		name    string
+		content string
+	}{
+		{"overrides.yaml", "53233:\n  text: Fixes pods.\n  section: Bug Fixes\n52602:\n  actionRequired: true\n53317:\n  drop: true\n"},
+		{"overrides.json", `{"53233": {"text": "Fixes pods.", "section": "Bug Fixes"}, "52602": {"actionRequired": true}, "53317": {"drop": true}}`},
+	}
+
+	for _, table := range tables {
+		file := filepath.Join(dir, table.name)
+		if err := ioutil.WriteFile(file, []byte(table.content), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		overrides, err := loadOverrides(file)
+		if err != nil {
+			t.Errorf("%s: Unexpected error: %v", table.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(overrides, want) {
+			t.Errorf("%s: Overrides were incorrect, want: %+v, got: %+v", table.name, want, overrides)
+		}
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "bad.yaml"), []byte("foo: {text: bar}\n"), 0644)
+	if _, err := loadOverrides(filepath.Join(dir, "bad.yaml")); err == nil {
+		t.Errorf("Expected error for non-numeric PR key")
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	sections := []NoteSection{
+		{Title: "Bug Fixes", Entries: []NoteEntry{{Number: 1, Text: "One"}, {Number: 2, Text: "Two"}}},
+		{Title: "Uncategorized", Entries: []NoteEntry{{Number: 3, Text: "Three"}, {Number: 4, Text: "Four"}, {Number: 5, Text: "Five"}}},
+	}
+	overrides := map[int]NoteOverride{
+		1:  {Text: "One, curated"},
+		2:  {ActionRequired: true},
+		3:  {Section: "Bug Fixes"},
+		4:  {Drop: true},
+		5:  {Section: "Known Issues", Text: "Five, curated"},
+		99: {Drop: true},
+		42: {Text: "Missing"},
+	}
+
+	sections, unused := applyOverrides(sections, overrides)
+	want := []NoteSection{
+		{Title: "Action Required", Entries: []NoteEntry{{Number: 2, Text: "Two", ActionRequired: true}}},
+		{Title: "Bug Fixes", Entries: []NoteEntry{{Number: 1, Text: "One, curated"}, {Number: 3, Text: "Three"}}},
+		{Title: "Uncategorized", Entries: []NoteEntry{}},
+		{Title: "Known Issues", Entries: []NoteEntry{{Number: 5, Text: "Five, curated"}}},
+	}
+	if !reflect.DeepEqual(sections, want) {
+		t.Errorf("Sections were incorrect, want: %+v, got: %+v", want, sections)
+	}
+	if !reflect.DeepEqual(unused, []int{42, 99}) {
+		t.Errorf("Unused overrides were incorrect, want: %v, got: %v", []int{42, 99}, unused)
+	}
+}
@@ -0,0 +1,351 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	u "k8s.io/release/toolbox/util"
+)
+
+const (
+	// cherryPickApprovedLabel and cherryPickCandidateLabel are the labels of the PRs against a
+	// release branch whose cherry-pick is approved by the branch manager, or requested.
+	cherryPickApprovedLabel  = "cherrypick-approved"
+	cherryPickCandidateLabel = "cherrypick-candidate"
+
+	// Cherry-pick approval states of pending PRs, in display order.
+	cherryPickApproved     = "approved"
+	cherryPickCandidate    = "candidate"
+	cherryPickNotRequested = "not requested"
+
+	// Orders of the pending PRs of preview reports (--pending-sort).
+	pendingSortWaiting  = "waiting"
+	pendingSortNumber   = "number"
+	pendingSortAuthor   = "author"
+	pendingSortApproved = "approved"
+	pendingSortChecks   = "checks"
+
+	// previewTemplate is the markdown layout of the "relnotes preview" report. It is executed with
+	// a *PreviewReport, and parsed along with defaultTemplate so it can reuse its blocks.
+	previewTemplate = `{{- define "preview" }}# Release Preview of the {{ .Branch }} branch
+
+**Generated on {{ date .GeneratedAt }}**
+
+Last release | Released | Unreleased notes | Pending PRs | CI signal
+------------ | -------- | ---------------- | ----------- | ---------
+{{ .LastRelease }} | {{ .LastReleaseAgeDays }} days ago ({{ date .LastReleaseDate }}) | {{ .UnreleasedCount }} | {{ .PendingCount }} | {{ template "ciState" .CI }}
+
+## Pending PRs
+
+{{ range .PendingPRs }}### Milestone {{ .Milestone }}, cherry-pick {{ .CherryPick }}
+
+PR | Author | Waiting | Approved | Missing labels | Checks | Title
+-- | ------ | ------- | -------- | -------------- | ------ | -----
+{{ range .PRs }}#{{ .Number }} | @{{ .Author }} | {{ with .Status }}{{ days .Waiting }} | {{ if .Approved }}yes{{ else }}no{{ end }} | {{ join ", " .MissingLabels }} | {{ .ChecksState }}{{ with .FailedChecks }} ({{ join ", " . }}){{ end }}{{ else }} | | | {{ end }} | {{ stripStars .Title }}
+{{ end }}
+{{ else }}**No pending PRs**
+
+{{ end -}}
+## Unreleased notes since {{ .LastRelease }}
+
+{{ range .Unreleased }}{{ if .Entries }}### {{ .Title }}
+
+{{ range .Entries }}{{ template "entry" . }}
+{{ end }}
+{{ end }}{{ end }}
+{{- if not (hasEntries .Unreleased) }}**No unreleased notes**
+
+{{ end }}
+{{- with .CI }}## CI signal
+
+{{ template "ciState" . }}
+
+` + "```" + `
+{{ trim .Details }}
+` + "```" + `
+{{ end }}
+{{- end }}
+
+{{- define "ciState" }}{{ if not . }}unknown{{ else if .Green }}GOOD TO GO{{ else }}NOT READY{{ end }}{{ end }}`
+)
+
+// PreviewReport is the state of a branch between releases: the notes merged since its last
+// release, the PRs still pending on it and its CI signal.
+type PreviewReport struct {
+	Branch      string    `json:"branch"`
+	Head        string    `json:"head"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	// LastRelease is the last release of the branch, released at LastReleaseDate (the date of its
+	// commit)
+	LastRelease        string    `json:"lastRelease"`
+	LastReleaseDate    time.Time `json:"lastReleaseDate"`
+	LastReleaseAgeDays int       `json:"lastReleaseAgeDays"`
+
+	// PendingPRs are the open PRs against the branch, grouped by milestone and cherry-pick state
+	PendingPRs   []PendingGroup `json:"pendingPRs"`
+	PendingCount int            `json:"pendingCount"`
+	// Unreleased are the notes of the PRs merged since the last release
+	Unreleased      []NoteSection `json:"unreleased"`
+	UnreleasedCount int           `json:"unreleasedCount"`
+	// CI is only set for kubernetes/kubernetes, see findGreenBuild
+	CI *CISignal `json:"ci,omitempty"`
+}
+
+// PendingGroup is a group of pending PRs of the same milestone and cherry-pick state.
+type PendingGroup struct {
+	Milestone  string      `json:"milestone"`
+	CherryPick string      `json:"cherryPick"`
+	PRs        []PendingPR `json:"prs"`
+}
+
+// CISignal is the state of the CI jobs of a branch.
+type CISignal struct {
+	Green   bool   `json:"green"`
+	Details string `json:"details"`
+}
+
+// runPreview runs the "relnotes preview" subcommand: it gathers the preview report of the branch
+// and writes it in the output format (markdown or json), and in HTML with --html-file.
+func runPreview(g *u.GithubClient) error {
+	if *format != formatMarkdown && *format != formatJSON {
+		return fmt.Errorf("preview reports can't be written in %s format, use markdown or json", *format)
+	}
+	if _, ok := pendingSortKeys[*pendingSort]; !ok {
+		return fmt.Errorf("unknown pending PR order %q", *pendingSort)
+	}
+
+	report, err := gatherPreview(g, *owner, *repo, *branch)
+	if err != nil {
+		return fmt.Errorf("failed to gather preview report: %v", err)
+	}
+
+	var b bytes.Buffer
+	if *format == formatJSON {
+		err = writePreviewJSON(&b, report)
+	} else {
+		err = writePreviewMarkdown(&b, report, *htmlizeMD)
+	}
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(*mdFileName, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write preview report %s: %v", *mdFileName, err)
+	}
+	if *htmlFileName != "" {
+		if err = writePreviewHTMLFile(*htmlFileName, *htmlCSSFile, report); err != nil {
+			return err
+		}
+	}
+
+	if !*quiet {
+		log.Printf("Displaying the %s preview report to stdout...", *format)
+		fmt.Print(b.String())
+	}
+	return nil
+}
+
+// gatherPreview gathers the preview report of input branch in owner/repo.
+func gatherPreview(g *u.GithubClient, owner, repo, branch string) (*PreviewReport, error) {
+	info, err := gatherReleaseInfo(g, owner, repo, branch, "")
+	if err != nil {
+		return nil, err
+	}
+	report := &PreviewReport{
+		Branch:      branch,
+		Head:        info.branchHead,
+		GeneratedAt: time.Now(),
+		LastRelease: info.startTag,
+	}
+
+	log.Printf("Getting the date of %s...", info.startTag)
+	tags, err := g.ListAllTags(owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch repo tags: %v", err)
+	}
+	report.LastReleaseDate, err = g.GetCommitDate(owner, repo, info.startTag, tags)
+	if err != nil {
+		return nil, err
+	}
+	report.LastReleaseAgeDays = int(report.GeneratedAt.Sub(report.LastReleaseDate).Hours() / 24)
+
+	if *categorize {
+		categories, err := parseCategories(*categoryMap)
+		if err != nil {
+			return nil, err
+		}
+		report.Unreleased = categorizedRelease(info, categories)
+	} else {
+		report.Unreleased = patchRelease(info)
+	}
+	for _, s := range report.Unreleased {
+		report.UnreleasedCount += len(s.Entries)
+	}
+
+	prs, err := listPendingPRs(g, owner, repo, branch, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending PRs: %v", err)
+	}
+	sortPendingPRs(prs, *pendingSort)
+	report.PendingPRs = pendingGroups(prs)
+	report.PendingCount = len(prs)
+
+	if owner == "kubernetes" && repo == "kubernetes" {
+		report.CI = &CISignal{}
+		report.CI.Green, report.CI.Details = findGreenBuild(branch)
+	}
+	return report, nil
+}
+
+// pendingSortKeys compares pending PRs by each --pending-sort order. PRs without status, e.g. when
+// listed without it, sort last.
+var pendingSortKeys = map[string]func(a, b PendingPR) bool{
+	pendingSortWaiting: func(a, b PendingPR) bool {
+		return a.Status != nil && (b.Status == nil || a.Status.Waiting > b.Status.Waiting)
+	},
+	pendingSortNumber: func(a, b PendingPR) bool {
+		return a.Number < b.Number
+	},
+	pendingSortAuthor: func(a, b PendingPR) bool {
+		return strings.ToLower(a.Author) < strings.ToLower(b.Author)
+	},
+	pendingSortApproved: func(a, b PendingPR) bool {
+		return a.Status != nil && a.Status.Approved && (b.Status == nil || !b.Status.Approved)
+	},
+	pendingSortChecks: func(a, b PendingPR) bool {
+		states := map[string]int{"success": 1, "pending": 2, "failure": 3}
+		var sa, sb int
+		if a.Status != nil {
+			sa = states[a.Status.ChecksState]
+		}
+		if b.Status != nil {
+			sb = states[b.Status.ChecksState]
+		}
+		return sa > sb
+	},
+}
+
+// sortPendingPRs sorts input pending PRs in input --pending-sort order: longest waiting, lowest
+// number, author, approved first or failing checks first. Equal PRs keep their order.
+func sortPendingPRs(prs []PendingPR, key string) {
+	less := pendingSortKeys[key]
+	sort.SliceStable(prs, func(i, j int) bool { return less(prs[i], prs[j]) })
+}
+
+// cherryPickState returns the cherry-pick approval state of a pending PR from its labels.
+func cherryPickState(labels []string) string {
+	state := cherryPickNotRequested
+	for _, l := range labels {
+		switch l {
+		case cherryPickApprovedLabel:
+			return cherryPickApproved
+		case cherryPickCandidateLabel:
+			state = cherryPickCandidate
+		}
+	}
+	return state
+}
+
+// pendingGroups groups input pending PRs by milestone, sorted by name with PRs without milestone
+// last, then by cherry-pick state: approved, candidate and not requested. PRs keep their order
+// within a group.
+func pendingGroups(prs []PendingPR) []PendingGroup {
+	states := map[string]int{cherryPickApproved: 0, cherryPickCandidate: 1, cherryPickNotRequested: 2}
+	index := make(map[[2]string]int)
+	groups := make([]PendingGroup, 0)
+	for _, pr := range prs {
+		key := [2]string{pr.Milestone, cherryPickState(pr.Labels)}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, PendingGroup{Milestone: key[0], CherryPick: key[1], PRs: make([]PendingPR, 0)})
+		}
+		groups[i].PRs = append(groups[i].PRs, pr)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if a.Milestone != b.Milestone {
+			if a.Milestone == "null" || b.Milestone == "null" {
+				return b.Milestone == "null"
+			}
+			return a.Milestone < b.Milestone
+		}
+		return states[a.CherryPick] < states[b.CherryPick]
+	})
+	return groups
+}
+
+// writePreviewJSON renders input preview report as indented JSON.
+func writePreviewJSON(w io.Writer, report *PreviewReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// writePreviewMarkdown renders input preview report as markdown.
+func writePreviewMarkdown(w io.Writer, report *PreviewReport, htmlize bool) error {
+	t, _, err := newNotesTemplate("", htmlize)
+	if err != nil {
+		return err
+	}
+	if _, err = t.New("preview").Parse(previewTemplate); err != nil {
+		return fmt.Errorf("failed to parse preview template: %v", err)
+	}
+	if err = t.ExecuteTemplate(w, "preview", report); err != nil {
+		return fmt.Errorf("failed to render preview report: %v", err)
+	}
+	return nil
+}
+
+// writePreviewHTMLFile renders input preview report as a standalone HTML document in filename,
+// with linked references and the stylesheet of cssFileName if given.
+func writePreviewHTMLFile(filename, cssFileName string, report *PreviewReport) error {
+	log.Print("Generating HTML preview report...")
+	var md bytes.Buffer
+	if err := writePreviewMarkdown(&md, report, false); err != nil {
+		return err
+	}
+	css, err := readCSS(cssFileName)
+	if err != nil {
+		return err
+	}
+
+	prs := notePRs(&ReleaseNotes{Sections: report.Unreleased})
+	for _, g := range report.PendingPRs {
+		for _, pr := range g.PRs {
+			prs = append(prs, pr.Number)
+		}
+	}
+	r := newLinkRewriter(*owner, *repo, "CHANGELOG"+branchVerSuffix+".md", prs)
+
+	var b bytes.Buffer
+	if err = renderHTML(&b, []byte(r.rewrite(md.String())), "Release Preview of "+report.Branch, css); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filename, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write html file %s: %v", filename, err)
+	}
+	return nil
+}
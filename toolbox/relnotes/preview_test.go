@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	u "k8s.io/release/toolbox/util"
+)
+
+func TestCherryPickState(t *testing.T) {
+	tables := []struct {
+		labels []string
+		want   string
+	}{
+		{nil, cherryPickNotRequested},
+		{[]string{"sig/node", "cherrypick-candidate"}, cherryPickCandidate},
+		{[]string{"cherrypick-candidate", "cherrypick-approved"}, cherryPickApproved},
+		{[]string{"cherrypick-approved", "cherrypick-candidate"}, cherryPickApproved},
+	}
+
+	for _, table := range tables {
+		if got := cherryPickState(table.labels); got != table.want {
+			t.Errorf("Cherry-pick state of %v was incorrect, want: %v, got: %v", table.labels, table.want, got)
+		}
+	}
+}
+
+func TestPendingGroups(t *testing.T) {
+	prs := []PendingPR{
+		{Number: 1, Milestone: "null"},
+		{Number: 2, Milestone: "v1.8", Labels: []string{"cherrypick-candidate"}},
+		{Number: 3, Milestone: "v1.8"},
+		{Number: 4, Milestone: "v1.8", Labels: []string{"cherrypick-approved"}},
+		{Number: 5, Milestone: "v1.7", Labels: []string{"cherrypick-approved"}},
+		{Number: 6, Milestone: "v1.8", Labels: []string{"cherrypick-candidate"}},
+	}
+
+	type group struct {
+		milestone  string
+		cherryPick string
+		prs        []int
+	}
+	var got []group
+	for _, g := range pendingGroups(prs) {
+		var numbers []int
+		for _, pr := range g.PRs {
+			numbers = append(numbers, pr.Number)
+		}
+		got = append(got, group{g.Milestone, g.CherryPick, numbers})
+	}
+	want := []group{
+		{"v1.7", cherryPickApproved, []int{5}},
+		{"v1.8", cherryPickApproved, []int{4}},
+		{"v1.8", cherryPickCandidate, []int{2, 6}},
+		{"v1.8", cherryPickNotRequested, []int{3}},
+		{"null", cherryPickNotRequested, []int{1}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Pending groups were incorrect, want: %v, got: %v", want, got)
+	}
+}
+
+func TestSortPendingPRs(t *testing.T) {
+	status := func(waiting time.Duration, approved bool, checks string) *u.PRStatus {
+		return &u.PRStatus{Waiting: waiting, Approved: approved, ChecksState: checks}
+	}
+	newPRs := func() []PendingPR {
+		return []PendingPR{
+			{Number: 3, Author: "liggitt", Status: status(time.Hour, false, "success")},
+			{Number: 1, Author: "thockin"},
+			{Number: 4, Author: "Bob", Status: status(3*time.Hour, true, "failure")},
+			{Number: 2, Author: "alice", Status: status(2*time.Hour, true, "pending")},
+		}
+	}
+
+	tables := []struct {
+		key  string
+		want []int
+	}{
+		{pendingSortWaiting, []int{4, 2, 3, 1}},
+		{pendingSortNumber, []int{1, 2, 3, 4}},
+		{pendingSortAuthor, []int{2, 4, 3, 1}},
+		{pendingSortApproved, []int{4, 2, 3, 1}},
+		{pendingSortChecks, []int{4, 2, 3, 1}},
+	}
+
+	for _, table := range tables {
+		prs := newPRs()
+		sortPendingPRs(prs, table.key)
+		var got []int
+		for _, pr := range prs {
+			got = append(got, pr.Number)
+		}
+		if !reflect.DeepEqual(got, table.want) {
+			t.Errorf("%s: Order was incorrect, want: %v, got: %v", table.key, table.want, got)
+		}
+	}
+}
+
+// newTestPreview creates the preview report of the release-1.8 branch, 12 days after v1.8.1.
+func newTestPreview() *PreviewReport {
+	released := time.Date(2017, time.October, 12, 10, 0, 0, 0, time.UTC)
+	updated := time.Date(2017, time.October, 20, 10, 0, 0, 0, time.UTC)
+	return &PreviewReport{
+		Branch:             "release-1.8",
+		Head:               "0123456789abcdef",
+		GeneratedAt:        released.Add(12 * 24 * time.Hour),
+		LastRelease:        "v1.8.1",
+		LastReleaseDate:    released,
+		LastReleaseAgeDays: 12,
+		PendingPRs: pendingGroups([]PendingPR{
+			{54773, "v1.8", "liggitt", updated, "Fix **bold** title", []string{"cherrypick-approved"}, &u.PRStatus{
+				Approved:     true,
+				ChecksState:  "failure",
+				FailedChecks: []string{"pull-kubernetes-e2e-gce", "pull-kubernetes-verify"},
+				Waiting:      50 * time.Hour,
+			}},
+			{54774, "v1.8", "thockin", updated, "Bump version", []string{"cherrypick-approved"}, nil},
+		}),
+		PendingCount:    2,
+		Unreleased:      patchRelease(newTestReleaseInfo()),
+		UnreleasedCount: 3,
+		CI:              &CISignal{Green: false, Details: "ci-kubernetes-e2e-gce failed\n"},
+	}
+}
+
+func TestWritePreviewMarkdown(t *testing.T) {
+	var b bytes.Buffer
+	if err := writePreviewMarkdown(&b, newTestPreview(), false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		"# Release Preview of the release-1.8 branch\n\n**Generated on Tue Oct  24 10:00:00 UTC 2017**\n\n",
+		"v1.8.1 | 12 days ago (Thu Oct  12 10:00:00 UTC 2017) | 3 | 2 | NOT READY\n",
+		"### Milestone v1.8, cherry-pick approved\n\n" +
+			"PR | Author | Waiting | Approved | Missing labels | Checks | Title\n" +
+			"-- | ------ | ------- | -------- | -------------- | ------ | -----\n" +
+			"#54773 | @liggitt | 2 days | yes |  | failure (pull-kubernetes-e2e-gce, pull-kubernetes-verify) | Fix bold title\n" +
+			"#54774 | @thockin |  | | |  | Bump version\n\n",
+		"## Unreleased notes since v1.8.1\n\n### Action Required\n\n",
+		"* Fixes a performance issue when deleting pods. (#53233, @liggitt)\n",
+		"## CI signal\n\nNOT READY\n\n```\nci-kubernetes-e2e-gce failed\n```\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Preview missing %q:\n%s", want, b.String())
+		}
+	}
+
+	report := &PreviewReport{Branch: "master", LastRelease: "v1.9.0-alpha.1"}
+	b.Reset()
+	if err := writePreviewMarkdown(&b, report, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{" | 0 | 0 | unknown\n", "**No pending PRs**\n", "**No unreleased notes**\n"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Empty preview missing %q:\n%s", want, b.String())
+		}
+	}
+	if strings.Contains(b.String(), "## CI signal") {
+		t.Errorf("Expected no CI signal section:\n%s", b.String())
+	}
+}
+
+func TestWritePreviewJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := writePreviewJSON(&b, newTestPreview()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var got PreviewReport
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got.LastRelease != "v1.8.1" || got.LastReleaseAgeDays != 12 || got.PendingPRs[0].CherryPick != cherryPickApproved ||
+		got.CI == nil || got.CI.Green {
+		t.Errorf("Preview report was incorrect, got: %+v", got)
+	}
+}
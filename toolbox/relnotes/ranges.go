@@ -0,0 +1,113 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/github"
+	u "k8s.io/release/toolbox/util"
+)
+
+// releaseTagMinor matches release tags and captures their minor version, e.g. "1.8" in "v1.8.2"
+// or "v1.8.0-beta.1".
+var releaseTagMinor = regexp.MustCompile("^v([0-9]+\\.[0-9]+)\\.[0-9]+(-(alpha|beta|rc)\\.[0-9]+)?$")
+
+// splitRange splits a range of two refs in the format of start..end, e.g. "v1.7.8..v1.8.2".
+// Git ref names can't contain "..".
+func splitRange(r string) (start, end string, ok bool) {
+	refs := strings.SplitN(r, "..", 2)
+	if len(refs) != 2 || refs[0] == "" || refs[1] == "" {
+		return "", "", false
+	}
+	return refs[0], refs[1], true
+}
+
+// onBranch checks if input range of refs are release tags of the minor version of input branch
+// (of any minor version for master), in which case the commits of the range are the ones of the
+// branch between the dates of the tags. Other ranges, e.g. "v1.7.8..v1.8.2" or ranges of commits,
+// are resolved by reachability, see rangePRs.
+func onBranch(branch, start, end string) bool {
+	s := releaseTagMinor.FindStringSubmatch(start)
+	e := releaseTagMinor.FindStringSubmatch(end)
+	if s == nil || e == nil || s[1] != e[1] {
+		return false
+	}
+	return branch == "master" || branch == "release-"+s[1]
+}
+
+// rangePRs returns the PRs merged in the commits reachable from end but not from start, in
+// owner/repo, which can be on different branches. PRs are dereferenced back to master from their
+// cherry-picks, and the ones already reachable from start (e.g. cherry-picked into start's
+// branch) are dropped, so that the PRs are the changes of an upgrade from start to end. Commits
+// are read from the clone at dir if any, or using the Github compare API otherwise.
+func rangePRs(g *u.GithubClient, owner, repo, dir, start, end string) ([]int, error) {
+	added, err := commitRangePRs(g, owner, repo, dir, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the commits of %s..%s: %v", start, end, err)
+	}
+	released, err := commitRangePRs(g, owner, repo, dir, end, start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the commits of %s..%s: %v", end, start, err)
+	}
+	return subtractPRs(added, released), nil
+}
+
+// cloneDir returns the local clone of owner/repo given by --repo-dir, if any. It only applies to
+// the --owner/--repo repository.
+func cloneDir(o, r string) string {
+	if o == *owner && r == *repo {
+		return *repoDir
+	}
+	return ""
+}
+
+// commitRangePRs returns the PRs of the commits reachable from end but not from start.
+func commitRangePRs(g *u.GithubClient, owner, repo, dir, start, end string) ([]int, error) {
+	var commits []*github.RepositoryCommit
+	if dir != "" {
+		messages, err := u.CommitMessages(dir, start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range messages {
+			commits = append(commits, &github.RepositoryCommit{Commit: &github.Commit{Message: github.String(m)}})
+		}
+	} else {
+		var err error
+		commits, err = g.CompareCommits(owner, repo, start, end)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return u.ParsePRFromCommit(commits)
+}
+
+// subtractPRs returns the PRs of a which aren't in b, in order.
+func subtractPRs(a, b []int) []int {
+	drop := make(map[int]bool)
+	for _, pr := range b {
+		drop[pr] = true
+	}
+	prs := make([]int, 0, len(a))
+	for _, pr := range a {
+		if !drop[pr] {
+			prs = append(prs, pr)
+		}
+	}
+	return prs
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	u "k8s.io/release/toolbox/util"
+)
+
+func TestSplitRange(t *testing.T) {
+	tables := []struct {
+		r          string
+		start, end string
+		ok         bool
+	}{
+		{"v1.7.8..v1.8.2", "v1.7.8", "v1.8.2", true},
+		{"origin/release-1.7..0123abc", "origin/release-1.7", "0123abc", true},
+		{"v1.8.0..", "", "", false},
+		{"v1.8.1", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, table := range tables {
+		start, end, ok := splitRange(table.r)
+		if start != table.start || end != table.end || ok != table.ok {
+			t.Errorf("%q: Range was incorrect, want: %q %q %v, got: %q %q %v", table.r, table.start, table.end, table.ok, start, end, ok)
+		}
+	}
+}
+
+func TestOnBranch(t *testing.T) {
+	tables := []struct {
+		branch, start, end string
+		want               bool
+	}{
+		{"release-1.8", "v1.8.0", "v1.8.2", true},
+		{"release-1.8", "v1.8.0-rc.1", "v1.8.0", true},
+		{"master", "v1.9.0-alpha.1", "v1.9.0-alpha.2", true},
+		{"release-1.8", "v1.7.8", "v1.8.2", false},
+		{"release-1.8", "v1.7.0", "v1.8.0", false},
+		{"master", "v1.7.8", "v1.8.2", false},
+		{"release-1.7", "v1.8.0", "v1.8.2", false},
+		{"release-1.8", "v1.8.0", "0123abc", false},
+	}
+
+	for _, table := range tables {
+		if got := onBranch(table.branch, table.start, table.end); got != table.want {
+			t.Errorf("%s %s..%s: On branch was incorrect, want: %v, got: %v", table.branch, table.start, table.end, table.want, got)
+		}
+	}
+}
+
+func TestRangePRs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "relnotes-ranges")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	for k, v := range map[string]string{
+		"GIT_AUTHOR_NAME":     "Test",
+		"GIT_AUTHOR_EMAIL":    "test@example.com",
+		"GIT_COMMITTER_NAME":  "Test",
+		"GIT_COMMITTER_EMAIL": "test@example.com",
+	} {
+		os.Setenv(k, v)
+	}
+
+	// #1 is released in both v1.7.8 and v1.8.2, #2 is cherry-picked into v1.7.8, #3 and #4 are
+	// only on master and #6 is cherry-picked into v1.8.2 before being merged on master
+	merge := func(pr string) []string {
+		return []string{"commit", "--allow-empty", "-m", "Merge pull request #" + pr + " from user/branch"}
+	}
+	cherryPick := func(pr, into string) []string {
+		return []string{"commit", "--allow-empty", "-m", "Merge pull request #9" + pr +
+			" from k8s-cherrypick-bot/automated-cherry-pick-of-#" + pr + "-upstream-release-" + into}
+	}
+	cmds := [][]string{
+		{"init"},
+		{"checkout", "-b", "master"},
+		{"commit", "--allow-empty", "-m", "initial commit"},
+		merge("1"),
+		{"branch", "release-1.7"},
+		merge("2"),
+		merge("3"),
+		{"checkout", "release-1.7"},
+		cherryPick("2", "1.7"),
+		{"tag", "v1.7.8"},
+		{"checkout", "master"},
+		merge("4"),
+		{"checkout", "-b", "release-1.8"},
+		cherryPick("6", "1.8"),
+		{"tag", "v1.8.2"},
+		{"checkout", "master"},
+		merge("6"),
+	}
+	for _, c := range cmds {
+		if _, err := u.Git(dir, c...); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	tables := []struct {
+		start, end string
+		want       []int
+	}{
+		{"v1.7.8", "v1.8.2", []int{6, 4, 3}},
+		{"v1.8.2", "master", []int{}},
+		{"v1.7.8", "master", []int{6, 4, 3}},
+		{"v1.8.2", "v1.7.8", []int{}},
+	}
+	for _, table := range tables {
+		got, err := rangePRs(nil, "kubernetes", "kubernetes", dir, table.start, table.end)
+		if err != nil {
+			t.Errorf("%s..%s: Unexpected error: %v", table.start, table.end, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, table.want) {
+			t.Errorf("%s..%s: PRs were incorrect, want: %v, got: %v", table.start, table.end, table.want, got)
+		}
+	}
+
+	if _, err := rangePRs(nil, "kubernetes", "kubernetes", dir, "v9.9.9", "master"); err == nil {
+		t.Errorf("Expected error for missing ref")
+	}
+}
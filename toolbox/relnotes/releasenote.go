@@ -0,0 +1,122 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// releaseNoteFence matches the opening fence of a release note block, e.g. "```release-note"
+	// or "``` Release-Note-Action-Required".
+	releaseNoteFence = regexp.MustCompile("(?i)^\\s*(`{3,}|~{3,})\\s*release[-_ ]?notes?(([-_ ]action[-_ ]required)?)\\s*$")
+	// closingFence matches any fence line.
+	closingFence = regexp.MustCompile("^\\s*(`{3,}|~{3,})\\s*$")
+	// htmlComment matches HTML comments, which PR templates use for instructions.
+	htmlComment = regexp.MustCompile("(?s)<!--.*?-->")
+	// bulletMarker matches the list marker of a markdown bullet line.
+	bulletMarker = regexp.MustCompile("^([-*+]|[0-9]+[.)])\\s+")
+)
+
+// noneNotes lists the (lower case) notes which explicitly state a PR needs no release note.
+var noneNotes = map[string]bool{
+	"none":            true,
+	"n/a":             true,
+	"na":              true,
+	"no":              true,
+	"no release note": true,
+	"-":               true,
+}
+
+// parseReleaseNote extracts the release note from the fenced "release-note" (or
+// "release-note-action-required") blocks of a PR body. The found result is false if the body has
+// no non-empty block; a found but empty note means the body explicitly states NONE. Notes of
+// several blocks are joined with newlines.
+func parseReleaseNote(body string) (note string, actionRequired, found bool) {
+	body = strings.Replace(body, "\r\n", "\n", -1)
+	body = strings.Replace(body, "\r", "\n", -1)
+	body = htmlComment.ReplaceAllString(body, "")
+
+	var notes []string
+	lines := strings.Split(body, "\n")
+	for i := 0; i < len(lines); i++ {
+		m := releaseNoteFence.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		fence := m[1]
+
+		var block []string
+		for i++; i < len(lines); i++ {
+			if c := closingFence.FindStringSubmatch(lines[i]); c != nil && c[1][0] == fence[0] && len(c[1]) >= len(fence) {
+				break
+			}
+			block = append(block, lines[i])
+		}
+
+		text := cleanReleaseNote(block)
+		if text == "" {
+			continue
+		}
+		found = true
+		if noneNotes[strings.ToLower(strings.TrimRight(text, "."))] {
+			continue
+		}
+		if m[2] != "" {
+			actionRequired = true
+		}
+		notes = append(notes, text)
+	}
+	return strings.Join(notes, "\n"), actionRequired, found
+}
+
+// cleanReleaseNote trims the lines of a release note block: trailing whitespace, leading and
+// trailing blank lines and common indentation are removed, as well as the list marker of the
+// first line so the note can be rendered as a list item.
+func cleanReleaseNote(lines []string) string {
+	for i := range lines {
+		lines[i] = strings.TrimRightFunc(lines[i], unicode.IsSpace)
+	}
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	indent := -1
+	for _, l := range lines {
+		if l == "" {
+			continue
+		}
+		n := len(l) - len(strings.TrimLeft(l, " \t"))
+		if indent == -1 || n < indent {
+			indent = n
+		}
+	}
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = l[indent:]
+		}
+	}
+
+	lines[0] = bulletMarker.ReplaceAllString(strings.TrimSpace(lines[0]), "")
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
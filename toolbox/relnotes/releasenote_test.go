@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/google/go-github/github"
+)
+
+func TestParseReleaseNote(t *testing.T) {
+	tables := []struct {
+		body           string
+		note           string
+		actionRequired bool
+		found          bool
+	}{
+		{"```release-note\r\nFixes a bug.\r\n```", "Fixes a bug.", false, true},
+		{"```release-note\nFixes a bug.\n```", "Fixes a bug.", false, true},
+		{"```release-note\rFixes a bug.\r```", "Fixes a bug.", false, true},
+		{"```release-note\nLine one.\nLine two.\n```", "Line one.\nLine two.", false, true},
+		{"```release-note\n* First.\n* Second.\n```", "First.\n* Second.", false, true},
+		{"```release-note\n  1. Indented.\n     Continued.\n```", "Indented.\n   Continued.", false, true},
+		{"```release-note-action-required\nRemoves --foo.\n```", "Removes --foo.", true, true},
+		{"``` Release-Note-Action-Required \nRemoves --foo.\n```", "Removes --foo.", true, true},
+		{"```RELEASE_NOTE\nFixes a bug.\n```", "Fixes a bug.", false, true},
+		{"~~~release-note\nFixes ``` in a bug.\n~~~", "Fixes ``` in a bug.", false, true},
+		{"```release-note\nNONE\n```", "", false, true},
+		{"```release-note\n none. \n```", "", false, true},
+		{"```release-note\nN/A\n```", "", false, true},
+		{"```release-note\n<!-- Write NONE if no note is needed -->\n```", "", false, false},
+		{"```release-note\n\n```", "", false, false},
+		{"```release-notes\nFixes a bug.\n```", "Fixes a bug.", false, true},
+		{"```release-note\nFirst block.\n```\n```release-note-action-required\nSecond block.\n```", "First block.\nSecond block.", true, true},
+		{"```release-note\nNONE\n```\n```release-note-action-required\nSecond block.\n```", "Second block.", true, true},
+		{"```release-note-action-required\nNONE\n```", "", false, true},
+		{"```release-note-foo\nNot a note.\n```", "", false, false},
+		{"Some text ```release-note\nNot a fence.\n```", "", false, false},
+		{"", "", false, false},
+	}
+
+	for _, table := range tables {
+		note, actionRequired, found := parseReleaseNote(table.body)
+		if note != table.note || actionRequired != table.actionRequired || found != table.found {
+			t.Errorf("%q: Release note was incorrect, want: %q %v %v, got: %q %v %v",
+				table.body, table.note, table.actionRequired, table.found, note, actionRequired, found)
+		}
+	}
+}
+
+// TestParseReleaseNoteCorpus checks the release notes extracted from the PR bodies in
+// testdata/pr-bodies. Each <PR>.md body has its expected result in <PR>.json.
+func TestParseReleaseNoteCorpus(t *testing.T) {
+	bodies, err := filepath.Glob("testdata/pr-bodies/*.md")
+	if err != nil || len(bodies) == 0 {
+		t.Fatalf("Failed to list corpus: %v", err)
+	}
+
+	for _, file := range bodies {
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		content, err := ioutil.ReadFile(strings.TrimSuffix(file, ".md") + ".json")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var want struct {
+			Note           string `json:"note"`
+			ActionRequired bool   `json:"actionRequired"`
+			Found          bool   `json:"found"`
+		}
+		if err := json.Unmarshal(content, &want); err != nil {
+			t.Fatalf("%s: Unexpected error: %v", file, err)
+		}
+
+		note, actionRequired, found := parseReleaseNote(string(body))
+		if note != want.Note || actionRequired != want.ActionRequired || found != want.Found {
+			t.Errorf("%s: Release note was incorrect, want: %q %v %v, got: %q %v %v",
+				file, want.Note, want.ActionRequired, want.Found, note, actionRequired, found)
+		}
+	}
+}
+
+// TestParseReleaseNoteFuzz checks properties of the parser on random input, alone and wrapped in
+// a release note block.
+func TestParseReleaseNoteFuzz(t *testing.T) {
+	// Any input: no panic, and a note is only returned when a block is found.
+	anyBody := func(body string) bool {
+		note, actionRequired, found := parseReleaseNote(body)
+		return found || (note == "" && !actionRequired)
+	}
+	if err := quick.Check(anyBody, &quick.Config{MaxCount: 1000}); err != nil {
+		t.Error(err)
+	}
+
+	// Fenced input: the note never has surrounding whitespace or fences, and doesn't depend on
+	// line endings.
+	fenced := func(text string) bool {
+		text = strings.Replace(text, "`", "", -1)
+		body := "Intro\n```release-note\n" + text + "\n```\nOutro"
+		note, _, _ := parseReleaseNote(body)
+		crlfNote, _, _ := parseReleaseNote(strings.Replace(body, "\n", "\r\n", -1))
+		return note == strings.TrimSpace(note) && !strings.Contains(note, "```") &&
+			!strings.Contains(note, "\r") && note == crlfNote
+	}
+	if err := quick.Check(fenced, &quick.Config{MaxCount: 1000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClassifyReleasePRs(t *testing.T) {
+	prMap := map[int]*github.Issue{
+		1: newTestPR(1, "a", "Note", "```release-note\nA note.\n```", "release-note"),
+		2: newTestPR(2, "a", "None", "```release-note\nNONE\n```", "release-note"),
+		3: newTestPR(3, "a", "Block", "```release-note-action-required\nAction.\n```", "release-note"),
+		4: newTestPR(4, "a", "Title", "", "release-note"),
+	}
+	actionRequiredPRMap := map[int]*github.Issue{
+		5: newTestPR(5, "a", "Label", "```release-note\nAction.\n```", "release-note-action-required"),
+	}
+
+	releasePRs, actionRequiredPRs := classifyReleasePRs([]int{1, 2, 3, 4, 5, 6}, prMap, actionRequiredPRMap)
+	if !reflect.DeepEqual(releasePRs, []int{1, 4}) {
+		t.Errorf("Release PRs were incorrect, want: %v, got: %v", []int{1, 4}, releasePRs)
+	}
+	if !reflect.DeepEqual(actionRequiredPRs, []int{3, 5}) {
+		t.Errorf("Action required PRs were incorrect, want: %v, got: %v", []int{3, 5}, actionRequiredPRs)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	formatMarkdown = "markdown"
+	formatJSON     = "json"
+	formatText     = "text"
+	formatChat     = "chat"
+	formatEmail    = "email"
+	formatAtom     = "atom"
+)
+
+// formatExtensions maps the output formats to the extension of their default file name.
+var formatExtensions = map[string]string{
+	formatMarkdown: "md",
+	formatJSON:     "json",
+	formatText:     "txt",
+	formatChat:     "json",
+	formatEmail:    "eml",
+	formatAtom:     "xml",
+}
+
+// writeNotesFile renders input release note into filename in the given format.
+func writeNotesFile(filename, format string, notes *ReleaseNotes) error {
+	var result error
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create release note file %s: %v", filename, err)
+	}
+	defer func() {
+		if err = f.Close(); err != nil {
+			result = fmt.Errorf("failed to close file %s, %v", filename, err)
+		}
+	}()
+
+	switch format {
+	case formatMarkdown:
+		err = writeMarkdown(f, notes)
+	case formatJSON:
+		err = writeJSON(f, notes)
+	case formatText:
+		err = writeText(f, notes)
+	case formatChat:
+		err = writeChat(f, notes, *chatMessageSize)
+	case formatEmail:
+		err = writeEmail(f, notes, *emailFrom, *emailTo)
+	case formatAtom:
+		err = writeAtom(f, notes)
+	default:
+		err = fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+	return result
+}
+
+// writeJSON renders input release note as indented JSON.
+func writeJSON(w io.Writer, notes *ReleaseNotes) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(notes)
+}
+
+// writeMarkdown renders input release note with the template given by --template, or the default
+// markdown template.
+func writeMarkdown(w io.Writer, notes *ReleaseNotes) error {
+	return renderTemplate(w, *templateFile, *htmlizeMD, notes)
+}
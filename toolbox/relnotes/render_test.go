@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	info := newTestReleaseInfo()
+	notes := &ReleaseNotes{
+		Title:      "v1.8.1",
+		Version:    "v1.8.1",
+		StartTag:   "v1.8.0",
+		Branch:     "release-1.8",
+		DocURL:     "https://docs.k8s.io",
+		ExampleURL: "https://releases.k8s.io/release-1.8/examples",
+		Sections:   patchRelease(info),
+		Downloads: []DownloadTable{
+			{"", []DownloadFile{{Name: "kubernetes.tar.gz", URL: "https://dl.k8s.io/v1.8.1/kubernetes.tar.gz", SHA256: "abc", SHA512: "def"}}},
+		},
+	}
+
+	want := `
+# v1.8.1
+
+[Documentation](https://docs.k8s.io) & [Examples](https://releases.k8s.io/release-1.8/examples)
+
+## Downloads for v1.8.1
+
+
+filename | sha256 hash | sha512 hash
+-------- | ----------- | -----------
+[kubernetes.tar.gz](https://dl.k8s.io/v1.8.1/kubernetes.tar.gz) | ` + "`abc` | `def`" + `
+
+## Changelog since v1.8.0
+
+### Action Required
+
+* The --foo flag was removed. (#52602, @thockin)
+
+### Other notable changes
+
+* Fixes a performance issue when deleting pods. (#53233, @liggitt)
+* Change default --cert-dir for kubelet (#53317, @liggitt)
+
+`
+	var b bytes.Buffer
+	if err := writeMarkdown(&b, notes); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if b.String() != want {
+		t.Errorf("Markdown was incorrect, want:\n%s\ngot:\n%s", want, b.String())
+	}
+
+	notes.Sections = []NoteSection{{Title: "Other notable changes"}}
+	notes.Downloads = nil
+	b.Reset()
+	writeMarkdown(&b, notes)
+	if !bytes.Contains(b.Bytes(), []byte("**No notable changes for this release**")) {
+		t.Errorf("Expected no notable changes, got:\n%s", b.String())
+	}
+}
+
+func TestWriteMarkdownMinor(t *testing.T) {
+	notes := &ReleaseNotes{
+		Title:            "v1.8.0",
+		Version:          "v1.8.0",
+		Minor:            true,
+		PreviousReleases: []string{"- [v1.8.0-rc.1](#v180-rc1)", "- [v1.8.0-beta.1](#v180-beta1)"},
+	}
+
+	var b bytes.Buffer
+	writeMarkdown(&b, notes)
+	for _, s := range []string{"## Major Themes\n\n* TBD\n", "### Previous Release Included in v1.8.0\n\n- [v1.8.0-rc.1](#v180-rc1)\n- [v1.8.0-beta.1](#v180-beta1)\n\n"} {
+		if !bytes.Contains(b.Bytes(), []byte(s)) {
+			t.Errorf("Markdown missing %q:\n%s", s, b.String())
+		}
+	}
+
+	notes.Draft = "## Major Themes\n\n* Workloads API goes beta\n"
+	b.Reset()
+	writeMarkdown(&b, notes)
+	if !bytes.Contains(b.Bytes(), []byte("* Workloads API goes beta\n")) || bytes.Contains(b.Bytes(), []byte("TBD")) {
+		t.Errorf("Markdown didn't use the draft:\n%s", b.String())
+	}
+	if bytes.Contains(b.Bytes(), []byte("## Changelog since")) {
+		t.Errorf("Unexpected changelog without aggregated notes:\n%s", b.String())
+	}
+
+	// Aggregated notes since the previous minor release
+	notes.StartTag = "v1.7.0"
+	notes.Sections = patchRelease(newTestReleaseInfo())
+	b.Reset()
+	writeMarkdown(&b, notes)
+	for _, s := range []string{
+		"* Workloads API goes beta\n",
+		"## Changelog since v1.7.0\n\n### Action Required\n\n* The --foo flag was removed. (#52602, @thockin)\n",
+	} {
+		if !bytes.Contains(b.Bytes(), []byte(s)) {
+			t.Errorf("Markdown missing %q:\n%s", s, b.String())
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	notes := &ReleaseNotes{
+		Title:    "v1.8.1",
+		Version:  "v1.8.1",
+		StartTag: "v1.8.0",
+		Sections: patchRelease(newTestReleaseInfo()),
+		Dependencies: &DepDiff{
+			Changed: []DepChange{{"github.com/bumped/dep", "v1.0.0", "v1.1.0"}},
+		},
+	}
+
+	var b bytes.Buffer
+	if err := writeJSON(&b, notes); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var decoded ReleaseNotes
+	if err := json.Unmarshal(b.Bytes(), &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decoded.Version != "v1.8.1" || decoded.StartTag != "v1.8.0" {
+		t.Errorf("Range was incorrect, got: %s..%s", decoded.StartTag, decoded.Version)
+	}
+	if len(decoded.Sections) != 2 || len(decoded.Sections[1].Entries) != 2 {
+		t.Fatalf("Sections were incorrect, got: %+v", decoded.Sections)
+	}
+	e := decoded.Sections[1].Entries[0]
+	if e.Number != 53233 || e.Kind != "bug" || e.SIG != "node" || len(e.Labels) != 3 {
+		t.Errorf("Entry was incorrect, got: %+v", e)
+	}
+	if decoded.Dependencies == nil || decoded.Dependencies.Changed[0].NewVersion != "v1.1.0" {
+		t.Errorf("Dependencies were incorrect, got: %+v", decoded.Dependencies)
+	}
+}
+
+func TestWriteMarkdownBySIG(t *testing.T) {
+	notes := &ReleaseNotes{
+		Title:    "v1.8.1",
+		StartTag: "v1.8.0",
+		Sections: []NoteSection{
+			{Title: "Action Required", Entries: []NoteEntry{}, Subsections: []NoteSection{}},
+			{Title: "Bug Fixes", Entries: []NoteEntry{}, Subsections: []NoteSection{
+				{Title: "SIG Node", Entries: []NoteEntry{{Number: 53233, Author: "liggitt", Text: "Fixes pods."}}},
+				{Title: "No SIG", Entries: []NoteEntry{{Number: 53317, Author: "liggitt", Text: "Fixes certs."}}},
+			}},
+		},
+	}
+
+	want := "## Changelog since v1.8.0\n\n### Bug Fixes\n\n" +
+		"#### SIG Node\n\n* Fixes pods. (#53233, @liggitt)\n\n" +
+		"#### No SIG\n\n* Fixes certs. (#53317, @liggitt)\n\n"
+	var b bytes.Buffer
+	if err := renderBlock(&b, "changelog", notes); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if b.String() != want {
+		t.Errorf("Markdown was incorrect, want:\n%s\ngot:\n%s", want, b.String())
+	}
+}
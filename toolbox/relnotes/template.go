@@ -0,0 +1,344 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	// defaultTemplateName is the name of the root template of defaultTemplate.
+	defaultTemplateName = "notes"
+
+	// defaultTemplate is the built-in markdown layout of release notes. It is executed with a
+	// *ReleaseNotes, and is made of named blocks that user-supplied templates can reuse with
+	// {{template "<name>" .}} or redefine with {{define "<name>"}}.
+	defaultTemplate = `{{- define "notes" }}
+{{- template "body" . }}
+{{- if .Repos }}{{ template "repos" . }}{{ else if .Minor }}{{ template "minor" . }}{{ if hasEntries .Sections }}{{ template "changelog" . }}{{ end }}{{ else }}{{ template "changelog" . }}{{ end }}
+{{- with .APIChanges }}{{ template "apiChanges" . }}{{ end }}
+{{- with .Dependencies }}{{ template "dependencies" . }}{{ end }}
+{{- if .Preview }}{{ template "pendingPRs" . }}{{ end }}
+{{- end }}
+
+{{- define "body" }}
+{{- if .Preview }}**Release Note Preview - generated on {{ date .GeneratedAt }}**
+{{ end }}
+# {{ .Title }}
+
+[Documentation]({{ .DocURL }}) & [Examples]({{ .ExampleURL }})
+
+{{ template "downloads" . }}
+{{- end }}
+
+{{- define "downloads" }}
+{{- if .Downloads }}## Downloads for {{ .Title }}
+
+{{ range .Downloads }}
+{{- if .Heading }}
+### {{ .Heading }}
+{{ end }}{{ $signed := .Signed }}
+filename | sha256 hash | sha512 hash{{ if $signed }} | signature{{ end }}
+-------- | ----------- | -----------{{ if $signed }} | ---------{{ end }}
+{{ range .Files }}[{{ .Name }}]({{ .URL }}) | ` + "`{{ .SHA256 }}` | `{{ .SHA512 }}`" + `
+{{- if $signed }} | {{ with .Signature }}[signature]({{ . }}){{ end }}{{ if and .Signature .Certificate }}, {{ end }}{{ with .Certificate }}[certificate]({{ . }}){{ end }}{{ end }}
+{{ end }}
+{{- end }}
+{{ end }}
+{{- end }}
+
+{{- define "minor" }}
+{{- if .Draft }}{{ .Draft }}
+{{ else }}## Major Themes
+
+* TBD
+
+## Other notable improvements
+
+* TBD
+
+## Known Issues
+
+{{ range .KnownIssues }}{{ template "knownIssue" . }}
+{{ else }}* TBD
+{{ end }}
+{{- with .Deprecations }}
+## Deprecations
+
+{{ range . }}{{ template "entry" . }}
+{{ end }}
+{{- end }}
+## Provider-specific Notes
+
+* TBD
+
+{{ end }}{{ with .Enhancements }}{{ template "enhancements" . }}{{ end }}### Previous Release Included in {{ .Version }}
+
+{{ if .PreviousReleases }}{{ range .PreviousReleases }}{{ . }}
+{{ end }}
+{{ end }}
+{{- end }}
+
+{{- define "changelog" }}## Changelog since {{ .StartTag }}
+
+{{ range .Sections }}{{ if or .Entries .Subsections }}### {{ .Title }}
+
+{{ with .Entries }}{{ range . }}{{ template "entry" . }}
+{{ end }}
+{{ end }}
+{{- range .Subsections }}{{ if .Entries }}#### {{ .Title }}
+
+{{ range .Entries }}{{ template "entry" . }}
+{{ end }}
+{{ end }}{{ end }}
+{{- end }}{{ end }}
+{{- if not (hasEntries .Sections) }}**No notable changes for this release**
+
+{{ end }}
+{{- end }}
+
+{{- define "repos" }}## Totals
+
+repository | range | release notes | action required | contributors
+---------- | ----- | ------------- | --------------- | ------------
+{{ range .Repos }}{{ .Repo }} | {{ .StartTag }}..{{ .Version }} | {{ .Notes }} | {{ .ActionRequired }} | {{ .Contributors }}
+{{ end }}{{ with .Totals }}**Total** | | {{ .Notes }} | {{ .ActionRequired }} | {{ .Contributors }}
+{{ end }}
+{{ range .Sections }}## {{ .Title }}
+
+{{ range .Subsections }}### {{ .Title }}
+
+{{ range .Entries }}{{ template "entry" . }}
+{{ end }}
+{{ else }}**No notable changes for this release**
+
+{{ end }}{{ end }}
+{{- end }}
+
+{{- define "enhancements" }}## Major Themes by stage
+
+{{ range . }}### {{ .Title }}
+
+{{ range .Enhancements }}{{ template "enhancement" . }}
+{{ end }}
+{{ end }}
+{{- end }}
+
+{{- define "enhancement" }}* {{ .Title }} ([#{{ .Number }}]({{ .URL }}))
+{{- range .Entries }}
+  * {{ indent 4 .Text }} ({{ .Repo }}#{{ .Number }}, @{{ .Author }})
+{{- end }}
+{{- end }}
+
+{{- define "knownIssue" }}* {{ .Title }} ([#{{ .Number }}]({{ .URL }})){{ end }}
+
+{{- define "entry" }}* {{ indent 2 .Text }} ({{ .Repo }}#{{ .Number }}, @{{ .Author }}){{ end }}
+
+{{- define "apiChanges" }}## API Changes
+
+{{ with .AddedGroupVersions }}### New API Group Versions
+
+{{ range . }}* ` + "`{{ . }}`" + `
+{{ end }}
+{{ end }}
+{{- with .RemovedGroupVersions }}### Removed API Group Versions
+
+{{ range . }}* ` + "`{{ . }}`" + `
+{{ end }}
+{{ end }}
+{{- with .AddedKinds }}### New Kinds
+
+{{ range . }}* ` + "`{{ . }}`" + `
+{{ end }}
+{{ end }}
+{{- with .RemovedKinds }}### Removed Kinds
+
+{{ range . }}* ` + "`{{ . }}`" + `
+{{ end }}
+{{ end }}
+{{- with .AddedFields }}### New Fields
+
+{{ range . }}* ` + "`{{ . }}`" + `
+{{ end }}
+{{ end }}
+{{- with .RemovedFields }}### Removed Fields
+
+{{ range . }}* ` + "`{{ . }}`" + `
+{{ end }}
+{{ end }}
+{{- with .Deprecations }}### Deprecations
+
+{{ range . }}* ` + "`{{ . }}`" + `
+{{ end }}
+{{ end }}
+{{- if not (or .AddedGroupVersions .RemovedGroupVersions .AddedKinds .RemovedKinds .AddedFields .RemovedFields .Deprecations) }}**No API changes for this release**
+
+{{ end }}
+{{- end }}
+
+{{- define "dependencies" }}## Dependencies
+
+{{ with .ManifestChange }}**The dependency manifest changed ({{ . }}), versions are compared across manifests**
+
+{{ end }}
+{{- if not (or .Added .Changed .Removed) }}**No dependency changes for this release**
+
+{{ end }}
+{{- with .Added }}### Added
+
+{{ range . }}* {{ .Name }}: {{ shortVersion .NewVersion }}
+{{ end }}
+{{ end }}
+{{- with .Changed }}### Changed
+
+{{ range . }}* {{ .Name }}: {{ shortVersion .OldVersion }} -> {{ shortVersion .NewVersion }}
+{{ end }}
+{{ end }}
+{{- with .Removed }}### Removed
+
+{{ range . }}* {{ .Name }}: {{ shortVersion .OldVersion }}
+{{ end }}
+{{ end }}
+{{- end }}
+
+{{- define "pendingPRs" }}-------
+## PENDING PRs on the {{ .Branch }} branch
+{{ if htmlize }}PR | Milestone | User | Date | Commit Message
+-- | --------- | ---- | ---- | --------------
+{{ end }}
+{{- range .PendingPRs }}
+{{- if htmlize }}{{ printf "#%-8d | %-4s | @%-10s| %s   | %s" .Number .Milestone .Author (date .Updated) (stripStars .Title) }}
+{{ else }}{{ printf "#%-8d  %-4s  @%-10s %s    %s" .Number .Milestone .Author (date .Updated) (stripStars .Title) }}
+{{ end }}
+{{- end }}
+
+{{ end }}`
+)
+
+// templateFuncs returns the helper functions available to release note templates:
+//
+//     date         formats a time.Time like date(1), e.g. "Mon Jan  2 15:04:05 MST 2006"
+//     days         formats a time.Duration in whole days, e.g. "3 days"
+//     hasEntries   reports whether any of input []NoteSection or their subsections has entries
+//     htmlize      reports whether PRs and contributors should be linked (--htmlize-md)
+//     indent       indents all the non-empty lines but the first of a string by n spaces
+//     join         joins a []string with a separator
+//     lower, upper changes the case of a string
+//     replace      replaces all occurrences of old with new in a string
+//     shortVersion shortens full commit SHAs to 12 characters
+//     stripStars   removes '*' from a string so it doesn't mess up markdown formatting
+//     trim         trims leading and trailing whitespace
+func templateFuncs(htmlize bool) template.FuncMap {
+	return template.FuncMap{
+		"date": func(t time.Time) string {
+			return t.Format("Mon Jan  2 15:04:05 MST 2006")
+		},
+		"days": func(d time.Duration) string {
+			if n := int(d.Hours() / 24); n != 1 {
+				return fmt.Sprintf("%d days", n)
+			}
+			return "1 day"
+		},
+		"hasEntries": hasEntries,
+		"htmlize":    func() bool { return htmlize },
+		"indent":     indent,
+		"join": func(sep string, s []string) string {
+			return strings.Join(s, sep)
+		},
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+		"replace": func(old, new, s string) string {
+			return strings.Replace(s, old, new, -1)
+		},
+		"shortVersion": shortVersion,
+		"stripStars": func(s string) string {
+			return strings.Replace(s, "*", "", -1)
+		},
+		"trim": strings.TrimSpace,
+	}
+}
+
+// hasEntries checks if any of input sections or their subsections has entries.
+func hasEntries(sections []NoteSection) bool {
+	for _, s := range sections {
+		if len(s.Entries) > 0 || hasEntries(s.Subsections) {
+			return true
+		}
+	}
+	return false
+}
+
+// indent indents all the non-empty lines but the first of input string by n spaces, so multi-line
+// release notes stay in their list item.
+func indent(n int, s string) string {
+	lines := strings.Split(s, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = strings.Repeat(" ", n) + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// newNotesTemplate parses the default template, and input template file if any, into one
+// template set. It returns the set and the name of the template to execute: the default root
+// template, or the user-supplied one.
+func newNotesTemplate(templateFile string, htmlize bool) (*template.Template, string, error) {
+	t, err := template.New(defaultTemplateName).Funcs(templateFuncs(htmlize)).Parse(defaultTemplate)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse default template: %v", err)
+	}
+	if templateFile == "" {
+		return t, defaultTemplateName, nil
+	}
+
+	content, err := ioutil.ReadFile(templateFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read template file %s: %v", templateFile, err)
+	}
+	name := filepath.Base(templateFile)
+	if _, err = t.New(name).Parse(string(content)); err != nil {
+		return nil, "", fmt.Errorf("failed to parse template file %s: %v", templateFile, err)
+	}
+	return t, name, nil
+}
+
+// renderTemplate renders input release note with the given template file, or the default
+// template if templateFile is empty.
+func renderTemplate(w io.Writer, templateFile string, htmlize bool, notes *ReleaseNotes) error {
+	t, name, err := newNotesTemplate(templateFile, htmlize)
+	if err != nil {
+		return err
+	}
+	if err = t.ExecuteTemplate(w, name, notes); err != nil {
+		return fmt.Errorf("failed to render release notes: %v", err)
+	}
+	return nil
+}
+
+// renderBlock renders a single named block of the default template with input data.
+func renderBlock(w io.Writer, name string, data interface{}) error {
+	t, _, err := newNotesTemplate("", *htmlizeMD)
+	if err != nil {
+		return err
+	}
+	return t.ExecuteTemplate(w, name, data)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "relnotes-template")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	notes := &ReleaseNotes{
+		Title:    "v1.8.1",
+		Version:  "v1.8.1",
+		StartTag: "v1.8.0",
+		Sections: patchRelease(newTestReleaseInfo()),
+	}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{
+			// Custom layout
+			`{{ .StartTag }}..{{ .Version }}
+{{ range .Sections }}{{ upper .Title }}: {{ len .Entries }}
+{{ end }}`,
+			"v1.8.0..v1.8.1\nACTION REQUIRED: 1\nOTHER NOTABLE CHANGES: 2\n",
+		},
+		{
+			// Redefined block of the default template
+			`{{ define "entry" }}- {{ .Text }} [{{ .Kind }}/{{ .SIG }}]{{ end }}{{ template "changelog" . }}`,
+			"## Changelog since v1.8.0\n\n### Action Required\n\n- The --foo flag was removed. [cleanup/]\n\n" +
+				"### Other notable changes\n\n- Fixes a performance issue when deleting pods. [bug/node]\n- Change default --cert-dir for kubelet [/node]\n\n",
+		},
+	}
+	for i, test := range tests {
+		file := filepath.Join(dir, "notes.tmpl")
+		if err := ioutil.WriteFile(file, []byte(test.template), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var b bytes.Buffer
+		if err := renderTemplate(&b, file, false, notes); err != nil {
+			t.Errorf("%d: Unexpected error: %v", i, err)
+			continue
+		}
+		if b.String() != test.want {
+			t.Errorf("%d: Rendered notes were incorrect, want:\n%s\ngot:\n%s", i, test.want, b.String())
+		}
+	}
+
+	var b bytes.Buffer
+	if err := renderTemplate(&b, filepath.Join(dir, "missing.tmpl"), false, notes); err == nil {
+		t.Errorf("Expected error for missing template file")
+	}
+	ioutil.WriteFile(filepath.Join(dir, "bad.tmpl"), []byte("{{ .Title "), 0644)
+	if err := renderTemplate(&b, filepath.Join(dir, "bad.tmpl"), false, notes); err == nil {
+		t.Errorf("Expected error for invalid template file")
+	}
+}
+
+func TestPendingPRsBlock(t *testing.T) {
+	updated := time.Date(2017, time.October, 3, 10, 0, 0, 0, time.UTC)
+	notes := &ReleaseNotes{
+		Branch:     "release-1.8",
+		Preview:    true,
+		PendingPRs: []PendingPR{{54773, "v1.8", "liggitt", updated, "Fix **bold** title", nil, nil}},
+	}
+
+	tests := []struct {
+		htmlize bool
+		want    string
+	}{
+		{
+			false,
+			"-------\n## PENDING PRs on the release-1.8 branch\n" +
+				"#54773     v1.8  @liggitt    Tue Oct  3 10:00:00 UTC 2017    Fix bold title\n\n\n",
+		},
+		{
+			true,
+			"-------\n## PENDING PRs on the release-1.8 branch\n" +
+				"PR | Milestone | User | Date | Commit Message\n-- | --------- | ---- | ---- | --------------\n" +
+				"#54773    | v1.8 | @liggitt   | Tue Oct  3 10:00:00 UTC 2017   | Fix bold title\n\n\n",
+		},
+	}
+	for _, test := range tests {
+		tmpl, _, err := newNotesTemplate("", test.htmlize)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var b bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&b, "pendingPRs", notes); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if b.String() != test.want {
+			t.Errorf("Pending PRs were incorrect (htmlize: %v), want:\n%q\ngot:\n%q", test.htmlize, test.want, b.String())
+		}
+	}
+}
+
+func TestIndent(t *testing.T) {
+	tables := []struct {
+		s    string
+		want string
+	}{
+		{"One line", "One line"},
+		{"First\n* Second\n\nThird", "First\n  * Second\n\n  Third"},
+	}
+
+	for _, table := range tables {
+		if got := indent(2, table.s); got != table.want {
+			t.Errorf("Indented string was incorrect, want: %q, got: %q", table.want, got)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package checksum computes the digests of release artifacts, several algorithms in a single read
+// of each file and several files in parallel, and verifies directories against SHA*SUMS files.
+package checksum
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Algorithm is a digest algorithm.
+type Algorithm string
+
+// Supported digest algorithms.
+const (
+	SHA1   Algorithm = "sha1"
+	SHA256 Algorithm = "sha256"
+	SHA512 Algorithm = "sha512"
+)
+
+// Digests are the hex-encoded digests of a file by algorithm.
+type Digests map[Algorithm]string
+
+// newHash creates the hash of input algorithm.
+func newHash(a Algorithm) (hash.Hash, error) {
+	switch a {
+	case SHA1:
+		return sha1.New(), nil
+	case SHA256:
+		return sha256.New(), nil
+	case SHA512:
+		return sha512.New(), nil
+	}
+	return nil, fmt.Errorf("unsupported digest algorithm %q", a)
+}
+
+// algorithmOf guesses the algorithm of input hex-encoded digest from its length.
+func algorithmOf(digest string) (Algorithm, error) {
+	if _, err := hex.DecodeString(digest); err != nil {
+		return "", fmt.Errorf("invalid digest %q: %v", digest, err)
+	}
+	switch len(digest) {
+	case 2 * sha1.Size:
+		return SHA1, nil
+	case 2 * sha256.Size:
+		return SHA256, nil
+	case 2 * sha512.Size:
+		return SHA512, nil
+	}
+	return "", fmt.Errorf("digest %q is not a SHA1, SHA256 or SHA512 digest", digest)
+}
+
+// File computes the digests of input file for all input algorithms, in a single read.
+func File(filename string, algorithms ...Algorithm) (Digests, error) {
+	hashes := make([]hash.Hash, len(algorithms))
+	writers := make([]io.Writer, len(algorithms))
+	for i, a := range algorithms {
+		h, err := newHash(a)
+		if err != nil {
+			return nil, err
+		}
+		hashes[i], writers[i] = h, h
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := io.Copy(io.MultiWriter(writers...), f); err != nil {
+		return nil, err
+	}
+
+	digests := make(Digests)
+	for i, a := range algorithms {
+		digests[a] = hex.EncodeToString(hashes[i].Sum(nil))
+	}
+	return digests, nil
+}
+
+// Files computes the digests of input files for all input algorithms, with up to workers files
+// read in parallel (the number of CPUs if workers isn't positive). The digests are indexed by
+// file name. The error is the one of the first file which couldn't be read, if any.
+func Files(filenames []string, workers int, algorithms ...Algorithm) (map[string]Digests, error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	for _, a := range algorithms {
+		if _, err := newHash(a); err != nil {
+			return nil, err
+		}
+	}
+
+	digests := make([]Digests, len(filenames))
+	errs := make([]error, len(filenames))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				digests[i], errs[i] = File(filenames[i], algorithms...)
+			}
+		}()
+	}
+	for i := range filenames {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	result := make(map[string]Digests, len(filenames))
+	for i, f := range filenames {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("failed to hash %s: %v", f, errs[i])
+		}
+		result[f] = digests[i]
+	}
+	return result, nil
+}
+
+// ParseSums parses a SHA*SUMS file in the format of sha256sum, i.e. lines of a hex-encoded digest
+// and a file name, separated by two spaces (or " *" for binary mode). It returns the digests by
+// file name.
+func ParseSums(r io.Reader) (map[string]string, error) {
+	sums := make(map[string]string)
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		l := strings.TrimRight(s.Text(), "\r")
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		i := strings.Index(l, " ")
+		if i == -1 || i+2 > len(l) || (l[i+1] != ' ' && l[i+1] != '*') {
+			return nil, fmt.Errorf("line %d: invalid checksum line %q", line, l)
+		}
+		digest, name := strings.ToLower(l[:i]), path.Clean(l[i+2:])
+		if _, err := algorithmOf(digest); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		sums[name] = digest
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
+
+// Report is the result of the verification of a directory against a SHA*SUMS file. File names
+// are relative to the directory, and sorted.
+type Report struct {
+	// Mismatched files have a digest different from the one of the SHA*SUMS file
+	Mismatched []string
+	// Missing files are listed in the SHA*SUMS file but aren't in the directory
+	Missing []string
+	// Extra files are in the directory but aren't listed in the SHA*SUMS file. SHA*SUMS files
+	// themselves aren't reported
+	Extra []string
+}
+
+// OK checks if the directory matches the SHA*SUMS file exactly.
+func (r *Report) OK() bool {
+	return len(r.Mismatched)+len(r.Missing)+len(r.Extra) == 0
+}
+
+// Verify checks the files of input directory, and its subdirectories, against a SHA*SUMS file,
+// hashing up to workers files in parallel (see Files).
+func Verify(dir, sumsFile string, workers int) (*Report, error) {
+	f, err := os.Open(sumsFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sums, err := ParseSums(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", sumsFile, err)
+	}
+
+	present := make(map[string]bool)
+	err = filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			rel, err := filepath.Rel(dir, file)
+			if err != nil {
+				return err
+			}
+			present[filepath.ToSlash(rel)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %v", dir, err)
+	}
+
+	r := &Report{Mismatched: make([]string, 0), Missing: make([]string, 0), Extra: make([]string, 0)}
+	for name := range present {
+		base := filepath.Base(name)
+		if _, ok := sums[name]; !ok && !(strings.HasPrefix(base, "SHA") && strings.HasSuffix(base, "SUMS")) {
+			r.Extra = append(r.Extra, name)
+		}
+	}
+
+	// Hash the listed files, grouped by algorithm
+	byAlgorithm := make(map[Algorithm][]string)
+	for name, digest := range sums {
+		if !present[name] {
+			r.Missing = append(r.Missing, name)
+			continue
+		}
+		a, _ := algorithmOf(digest)
+		byAlgorithm[a] = append(byAlgorithm[a], filepath.Join(dir, filepath.FromSlash(name)))
+	}
+	for a, files := range byAlgorithm {
+		digests, err := Files(files, workers, a)
+		if err != nil {
+			return nil, err
+		}
+		for file, d := range digests {
+			rel, _ := filepath.Rel(dir, file)
+			if name := filepath.ToSlash(rel); d[a] != sums[name] {
+				r.Mismatched = append(r.Mismatched, name)
+			}
+		}
+	}
+
+	sort.Strings(r.Mismatched)
+	sort.Strings(r.Missing)
+	sort.Strings(r.Extra)
+	return r, nil
+}
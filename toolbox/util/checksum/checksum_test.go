@@ -0,0 +1,163 @@
+package checksum
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	sha1OfA   = "86f7e437faa5a7fce15d1ddcb9eaeaea377667b8"
+	sha256OfA = "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"
+	sha512OfA = "1f40fc92da241694750979ee6cf582f2d5d7d28e18335de05abc54d0560e0f5302860c652bf08d560252aa5e74210546f369fbbbce8c12cfc7957b2652fe9a75"
+	sha256OfB = "3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d"
+)
+
+// newTestDir creates a directory with input files and their content.
+func newTestDir(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "checksum")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestFile(t *testing.T) {
+	dir := newTestDir(t, map[string]string{"a": "a"})
+	defer os.RemoveAll(dir)
+
+	digests, err := File(filepath.Join(dir, "a"), SHA1, SHA256, SHA512)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := Digests{SHA1: sha1OfA, SHA256: sha256OfA, SHA512: sha512OfA}
+	if !reflect.DeepEqual(digests, want) {
+		t.Errorf("Digests were incorrect, want: %v, got: %v", want, digests)
+	}
+
+	if _, err := File(filepath.Join(dir, "a"), "md5"); err == nil {
+		t.Errorf("Expected error for unsupported algorithm")
+	}
+	if _, err := File(filepath.Join(dir, "missing"), SHA256); err == nil {
+		t.Errorf("Expected error for missing file")
+	}
+}
+
+func TestFiles(t *testing.T) {
+	files := make(map[string]string)
+	for i := 0; i < 20; i++ {
+		files[strings.Repeat("f", i+1)] = strings.Repeat("b", i%2+1)
+	}
+	files["a"] = "a"
+	dir := newTestDir(t, files)
+	defer os.RemoveAll(dir)
+
+	var filenames []string
+	for name := range files {
+		filenames = append(filenames, filepath.Join(dir, name))
+	}
+	for _, workers := range []int{0, 1, 4} {
+		digests, err := Files(filenames, workers, SHA256)
+		if err != nil {
+			t.Fatalf("%d workers: Unexpected error: %v", workers, err)
+		}
+		if len(digests) != len(filenames) {
+			t.Errorf("%d workers: Number of digests was incorrect, want: %d, got: %d", workers, len(filenames), len(digests))
+		}
+		if got := digests[filepath.Join(dir, "a")][SHA256]; got != sha256OfA {
+			t.Errorf("%d workers: Digest was incorrect, want: %v, got: %v", workers, sha256OfA, got)
+		}
+		if got := digests[filepath.Join(dir, "f")][SHA256]; got != sha256OfB {
+			t.Errorf("%d workers: Digest was incorrect, want: %v, got: %v", workers, sha256OfB, got)
+		}
+	}
+
+	_, err := Files(append(filenames, filepath.Join(dir, "missing")), 4, SHA256)
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Expected error for missing file, got: %v", err)
+	}
+}
+
+func TestParseSums(t *testing.T) {
+	content := sha256OfA + "  kubernetes.tar.gz\n" +
+		strings.ToUpper(sha256OfB) + " *bin/kubectl\r\n" +
+		"\n" +
+		sha1OfA + "  ./kubernetes-src.tar.gz\n"
+	sums, err := ParseSums(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"kubernetes.tar.gz":     sha256OfA,
+		"bin/kubectl":           sha256OfB,
+		"kubernetes-src.tar.gz": sha1OfA,
+	}
+	if !reflect.DeepEqual(sums, want) {
+		t.Errorf("Sums were incorrect, want: %v, got: %v", want, sums)
+	}
+
+	for _, content := range []string{
+		sha256OfA + "\n",
+		sha256OfA + " kubernetes.tar.gz\n",
+		"abc  kubernetes.tar.gz\n",
+		strings.Replace(sha256OfA, "a", "z", 1) + "  kubernetes.tar.gz\n",
+	} {
+		if _, err := ParseSums(strings.NewReader(content)); err == nil {
+			t.Errorf("%q: Expected error", content)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := newTestDir(t, map[string]string{
+		"kubernetes.tar.gz":     "a",
+		"kubernetes-src.tar.gz": "a",
+		"bin/kubectl":           "b",
+		"extra.tar.gz":          "a",
+		"SHA1SUMS":              "",
+	})
+	defer os.RemoveAll(dir)
+
+	sums := sha256OfA + "  kubernetes.tar.gz\n" +
+		sha512OfA + "  bin/kubectl\n" +
+		sha1OfA + "  kubernetes-src.tar.gz\n" +
+		sha256OfA + "  missing.tar.gz\n"
+	sumsFile := filepath.Join(dir, "SHA256SUMS")
+	if err := ioutil.WriteFile(sumsFile, []byte(sums), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	r, err := Verify(dir, sumsFile, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := &Report{
+		Mismatched: []string{"bin/kubectl"},
+		Missing:    []string{"missing.tar.gz"},
+		Extra:      []string{"extra.tar.gz"},
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("Report was incorrect, want: %+v, got: %+v", want, r)
+	}
+	if r.OK() {
+		t.Errorf("Expected verification to fail")
+	}
+
+	os.Remove(filepath.Join(dir, "extra.tar.gz"))
+	ioutil.WriteFile(sumsFile, []byte(sha256OfA+"  kubernetes.tar.gz\n"+sha256OfB+"  bin/kubectl\n"+sha1OfA+"  kubernetes-src.tar.gz\n"), 0644)
+	if r, err = Verify(dir, sumsFile, 0); err != nil || !r.OK() {
+		t.Errorf("Expected verification to succeed, got: %+v (%v)", r, err)
+	}
+}
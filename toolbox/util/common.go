@@ -15,15 +15,17 @@
 package util
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
-	"io"
-	"os"
 	"os/exec"
 	"strings"
+
+	"k8s.io/release/toolbox/util/checksum"
 )
 
+// ChangelogStub is the content of a new CHANGELOG-X.Y.md file, matching the one anago bootstraps
+// in its update_changelog step.
+const ChangelogStub = "<!-- BEGIN MUNGE: GENERATED_TOC -->\n\n<!-- END MUNGE: GENERATED_TOC -->\n\n<!-- NEW RELEASE NOTES ENTRY -->\n"
+
 // Shell runs a command and returns the result as a string.
 func Shell(name string, arg ...string) (string, error) {
 	c := exec.Command(name, arg...)
@@ -31,19 +33,14 @@ func Shell(name string, arg ...string) (string, error) {
 	return string(bytes), err
 }
 
-// GetSha256 calculates SHA256 for input file.
+// GetSha256 calculates SHA256 for input file. See the checksum package to compute several digests
+// or hash several files in parallel.
 func GetSha256(filename string) (string, error) {
-	f, err := os.Open(filename)
+	digests, err := checksum.File(filename, checksum.SHA256)
 	if err != nil {
 		return "", err
 	}
-	defer f.Close()
-
-	h := sha256.New()
-	if _, err := io.Copy(h, f); err != nil {
-		return "", err
-	}
-	return hex.EncodeToString(h.Sum(nil)), nil
+	return digests[checksum.SHA256], nil
 }
 
 // RenderProgressBar renders a progress bar by rewriting the current (assuming
@@ -19,7 +19,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http"
+	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -357,3 +360,420 @@ func AddQuery(query []string, queryParts ...string) []string {
 func (g GithubClient) GetBranch(ctx context.Context, owner, repo, branch string) (*github.Branch, *github.Response, error) {
 	return g.client.Repositories.GetBranch(ctx, owner, repo, branch)
 }
+
+// DetermineRange examines a Git branch range in the format of [[startTag..]endTag], and
+// determines a valid range. For example:
+//
+//     ""                       - last release to HEAD on the branch
+//     "v1.1.4.."               - v1.1.4 to HEAD
+//     "v1.1.4..v1.1.7"         - v1.1.4 to v1.1.7
+//     "v1.1.7"                 - last release on the branch to v1.1.7
+//
+// The function also returns the SHA of the branch HEAD.
+//
+// NOTE: the input branch must be the corresponding release branch w.r.t. input range. For example:
+//
+//     Getting "v1.1.4..v1.1.7" on branch "release-1.1" makes sense
+//     Getting "v1.1.4..v1.1.7" on branch "release-1.2" doesn't
+func (g GithubClient) DetermineRange(owner, repo, branch, branchRange string) (startTag, releaseTag, branchHead string, err error) {
+	b, _, err := g.GetBranch(context.Background(), owner, repo, branch)
+	if err != nil {
+		return "", "", "", err
+	}
+	branchHead = *b.Commit.SHA
+
+	lastRelease, err := g.LastReleases(owner, repo)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	// If lastRelease[branch] is unset, attempt to get the last release from the parent branch
+	// and then master
+	if i := strings.LastIndex(branch, "."); lastRelease[branch] == "" && i != -1 {
+		lastRelease[branch] = lastRelease[branch[:i]]
+	}
+	if lastRelease[branch] == "" {
+		lastRelease[branch] = lastRelease["master"]
+	}
+
+	// Regexp Example:
+	// This regexp matches the Git branch range in the format of [[startTag..]endTag]. For example:
+	//
+	//     ""
+	//     "v1.1.4.."
+	//     "v1.1.4..v1.1.7"
+	//     "v1.1.7"
+	re, _ := regexp.Compile("([v0-9.]*-*(alpha|beta|rc)*\\.*[0-9]*)\\.\\.([v0-9.]*-*(alpha|beta|rc)*\\.*[0-9]*)$")
+	tags := re.FindStringSubmatch(branchRange)
+	if tags != nil {
+		startTag = tags[1]
+		releaseTag = tags[3]
+	} else {
+		startTag = lastRelease[branch]
+		releaseTag = branchHead
+	}
+
+	if startTag == "" {
+		return "", "", "", fmt.Errorf("unable to set beginning of range automatically")
+	}
+	if releaseTag == "" {
+		releaseTag = branchHead
+	}
+
+	return startTag, releaseTag, branchHead, nil
+}
+
+// ListReleaseCommits lists all the commits on the branch between startTag and releaseTag.
+func (g GithubClient) ListReleaseCommits(owner, repo, branch, startTag, releaseTag string) ([]*github.RepositoryCommit, error) {
+	// Get all tags in the repository
+	tags, err := g.ListAllTags(owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch repo tags: %v", err)
+	}
+
+	// Get commits for specified branch and range
+	tStart, err := g.GetCommitDate(owner, repo, startTag, tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get start commit date for %s: %v", startTag, err)
+	}
+	tEnd, err := g.GetCommitDate(owner, repo, releaseTag, tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release commit date for %s: %v", releaseTag, err)
+	}
+
+	releaseCommits, err := g.ListAllCommits(owner, repo, branch, tStart, tEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch release repo commits: %v", err)
+	}
+	return releaseCommits, nil
+}
+
+// CompareCommits lists the commits reachable from head but not from base, oldest first, using
+// the Github compare API. The commits are fetched page by page, as the API only returns the first
+// 250 commits of a comparison otherwise.
+func (g GithubClient) CompareCommits(owner, repo, base, head string) ([]*github.RepositoryCommit, error) {
+	commits := make([]*github.RepositoryCommit, 0)
+	for page := 1; ; page++ {
+		// The go-github compare function doesn't support pagination options
+		u := fmt.Sprintf("repos/%s/%s/compare/%s...%s?per_page=100&page=%d", owner, repo, base, head, page)
+		req, err := g.client.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		c := new(github.CommitsComparison)
+		if _, err = g.client.Do(context.Background(), req, c); err != nil {
+			return nil, err
+		}
+		for i := range c.Commits {
+			commits = append(commits, &c.Commits[i])
+		}
+		if len(c.Commits) == 0 || len(commits) >= c.GetTotalCommits() {
+			break
+		}
+	}
+	return commits, nil
+}
+
+// ParsePRFromCommit goes through commit messages, and parse PR IDs for normal pull requests as
+// well as cherry picks.
+func ParsePRFromCommit(commits []*github.RepositoryCommit) ([]int, error) {
+	prs := make([]int, 0)
+	prsMap := make(map[int]bool)
+
+	// Regexp example:
+	// This regexp matches (Note that it supports multiple-source cherry pick)
+	//
+	// "automated-cherry-pick-of-#12345-#23412-"
+	// "automated-cherry-pick-of-#23791-"
+	reCherry, _ := regexp.Compile("automated-cherry-pick-of-(#[0-9]+-){1,}")
+	reCherryID, _ := regexp.Compile("#([0-9]+)-")
+	reMerge, _ := regexp.Compile("^Merge pull request #([0-9]+) from")
+
+	for _, c := range commits {
+		// Deref all PRs back to master
+		// Match cherry pick PRs first and then normal pull requests
+		// Paying special attention to automated cherrypicks that could have multiple
+		// sources
+		if cpStr := reCherry.FindStringSubmatch(*c.Commit.Message); cpStr != nil {
+			cpPRs := reCherryID.FindAllStringSubmatch(cpStr[0], -1)
+			for _, pr := range cpPRs {
+				id, err := strconv.Atoi(pr[1])
+				if err != nil {
+					return nil, err
+				}
+				if prsMap[id] == false {
+					prs = append(prs, id)
+					prsMap[id] = true
+				}
+			}
+		} else if pr := reMerge.FindStringSubmatch(*c.Commit.Message); pr != nil {
+			id, err := strconv.Atoi(pr[1])
+			if err != nil {
+				return nil, err
+			}
+			if prsMap[id] == false {
+				prs = append(prs, id)
+				prsMap[id] = true
+			}
+		}
+	}
+
+	return prs, nil
+}
+
+// CountIssues gets the total number of issues matching search query without fetching them.
+func (g GithubClient) CountIssues(query string) (int, error) {
+	so := &github.SearchOptions{
+		ListOptions: github.ListOptions{PerPage: 1},
+	}
+	for {
+		r, _, err := g.client.Search.Issues(context.Background(), query, so)
+		if err != nil {
+			if _, ok := err.(*github.RateLimitError); ok {
+				log.Printf("Hitting Github search API rate limit, sleeping for 30 seconds... error message: %v", err)
+				time.Sleep(30 * time.Second)
+				continue
+			}
+			return 0, err
+		}
+		return *r.Total, nil
+	}
+}
+
+// GetIssue gets the issue (or pull request) with input number, waiting if it hits the rate
+// limit.
+func (g GithubClient) GetIssue(owner, repo string, number int) (*github.Issue, error) {
+	for {
+		i, _, err := g.client.Issues.Get(context.Background(), owner, repo, number)
+		if err != nil {
+			if _, ok := err.(*github.RateLimitError); ok {
+				log.Printf("Hitting Github API rate limit, sleeping for 30 seconds... error message: %v", err)
+				time.Sleep(30 * time.Second)
+				continue
+			}
+			return nil, err
+		}
+		return i, nil
+	}
+}
+
+// ListAllReviews lists all reviews for given owner, repo and pull request number.
+func (g GithubClient) ListAllReviews(owner, repo string, number int) ([]*github.PullRequestReview, error) {
+	lo := &github.ListOptions{
+		Page:    1,
+		PerPage: 100,
+	}
+
+	reviews, resp, err := g.client.PullRequests.ListReviews(context.Background(), owner, repo, number, lo)
+	if err != nil {
+		return nil, err
+	}
+	lo.Page++
+
+	for lo.Page <= resp.LastPage {
+		re, _, err := g.client.PullRequests.ListReviews(context.Background(), owner, repo, number, lo)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range re {
+			reviews = append(reviews, r)
+		}
+		lo.Page++
+	}
+	return reviews, nil
+}
+
+// GetFileContent gets the content of the file at path in the repository at ref (branch, tag or
+// commit) using the Github contents API. Files too large for the contents API are downloaded
+// from their raw download URL instead. If the file doesn't exist at ref, the error satisfies
+// os.IsNotExist.
+func (g GithubClient) GetFileContent(owner, repo, path, ref string) ([]byte, error) {
+	opt := &github.RepositoryContentGetOptions{Ref: ref}
+	file, _, resp, err := g.client.Repositories.GetContents(context.Background(), owner, repo, path, opt)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, &os.PathError{Op: "get", Path: ref + ":" + path, Err: os.ErrNotExist}
+		}
+		return nil, err
+	}
+	if file == nil {
+		return nil, fmt.Errorf("%s at %s is a directory", path, ref)
+	}
+
+	content, err := file.GetContent()
+	if err == nil && (content != "" || file.GetSize() == 0) {
+		return []byte(content), nil
+	}
+
+	// Content isn't inlined for files over 1MB
+	if file.DownloadURL == nil {
+		return nil, fmt.Errorf("no content or download link for %s at %s", path, ref)
+	}
+	raw, err := http.Get(*file.DownloadURL)
+	if err != nil {
+		return nil, err
+	}
+	defer raw.Body.Close()
+	if raw.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s at %s: status code %d", path, ref, raw.StatusCode)
+	}
+	return ioutil.ReadAll(raw.Body)
+}
+
+// PRStatus is the review and CI status of an open pull request.
+type PRStatus struct {
+	// Approved is true if the PR has the lgtm and approved labels, or an approving review and no
+	// pending change request
+	Approved bool `json:"approved"`
+	// MissingLabels are the required labels the PR doesn't have, e.g. cherry-pick approval
+	MissingLabels []string `json:"missingLabels,omitempty"`
+	// ChecksState is the state of the required status checks of the PR head: success, pending or
+	// failure. FailedChecks and PendingChecks list the contexts which aren't green.
+	ChecksState   string   `json:"checksState"`
+	FailedChecks  []string `json:"failedChecks,omitempty"`
+	PendingChecks []string `json:"pendingChecks,omitempty"`
+	// Waiting is how long the PR has been open
+	Waiting time.Duration `json:"waiting"`
+}
+
+// RequiredStatusChecks lists the status check contexts required to merge into input branch. The
+// list is empty if the branch isn't protected.
+func (g GithubClient) RequiredStatusChecks(owner, repo, branch string) ([]string, error) {
+	checks, resp, err := g.client.Repositories.GetRequiredStatusChecks(context.Background(), owner, repo, branch)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			// Github also answers 404 when the token has no admin access to the repo, which
+			// can't be told apart from an unprotected branch
+			log.Printf("No required status checks found for %s/%s branch %s (%v): either the branch isn't protected or the token can't read its protection. All the status checks of the PRs are considered required.", owner, repo, branch, err)
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	return checks.Contexts, nil
+}
+
+// GetPRStatus gets the review and CI status of input open pull request, against input required
+// labels and status check contexts. If no status check is required, all the status checks of the
+// PR head are.
+func (g GithubClient) GetPRStatus(owner, repo string, pr *github.Issue, requiredLabels, requiredChecks []string) (*PRStatus, error) {
+	s := &PRStatus{
+		Approved:      HasLabel(pr, "lgtm") && HasLabel(pr, "approved"),
+		MissingLabels: make([]string, 0),
+		Waiting:       time.Since(pr.GetCreatedAt()),
+	}
+	for _, l := range requiredLabels {
+		if !HasLabel(pr, l) {
+			s.MissingLabels = append(s.MissingLabels, l)
+		}
+	}
+
+	if !s.Approved {
+		reviews, err := g.ListAllReviews(owner, repo, pr.GetNumber())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list reviews of #%d: %v", pr.GetNumber(), err)
+		}
+		s.Approved = ReviewsApproved(reviews)
+	}
+
+	p, _, err := g.client.PullRequests.Get(context.Background(), owner, repo, pr.GetNumber())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pull request #%d: %v", pr.GetNumber(), err)
+	}
+	statuses, err := g.ListAllStatuses(owner, repo, p.GetHead().GetSHA())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status of #%d: %v", pr.GetNumber(), err)
+	}
+	s.ChecksState, s.FailedChecks, s.PendingChecks = ChecksState(statuses, requiredChecks)
+	return s, nil
+}
+
+// ListAllStatuses lists the latest status of each context of input ref, from its combined status.
+// The statuses are fetched page by page, as repos can have more contexts than fit in one page.
+func (g GithubClient) ListAllStatuses(owner, repo, ref string) ([]github.RepoStatus, error) {
+	lo := &github.ListOptions{
+		Page:    1,
+		PerPage: 100,
+	}
+
+	statuses := make([]github.RepoStatus, 0)
+	for {
+		status, resp, err := g.client.Repositories.GetCombinedStatus(context.Background(), owner, repo, ref, lo)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status.Statuses...)
+		if resp.NextPage == 0 {
+			break
+		}
+		lo.Page = resp.NextPage
+	}
+	return statuses, nil
+}
+
+// ReviewsApproved checks if input reviews, in chronological order, approve a pull request: the
+// last review of at least one reviewer approves it, and the last review of none requests changes.
+func ReviewsApproved(reviews []*github.PullRequestReview) bool {
+	last := make(map[string]string)
+	for _, r := range reviews {
+		switch state := r.GetState(); state {
+		case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			last[r.GetUser().GetLogin()] = state
+		}
+	}
+
+	approved := false
+	for _, state := range last {
+		switch state {
+		case "CHANGES_REQUESTED":
+			return false
+		case "APPROVED":
+			approved = true
+		}
+	}
+	return approved
+}
+
+// ChecksState returns the state of input required status check contexts (all the statuses if
+// required is empty), from the latest statuses of a commit: failure if any of them failed,
+// pending if any of them is pending or missing, success otherwise. It also returns the failed
+// and pending contexts.
+func ChecksState(statuses []github.RepoStatus, required []string) (state string, failed, pending []string) {
+	byContext := make(map[string]string)
+	for _, s := range statuses {
+		// Statuses are sorted by date, latest first
+		if _, ok := byContext[s.GetContext()]; !ok {
+			byContext[s.GetContext()] = s.GetState()
+		}
+	}
+	if len(required) == 0 {
+		for _, s := range statuses {
+			if byContext[s.GetContext()] != "" {
+				required = append(required, s.GetContext())
+			}
+		}
+	}
+
+	failed, pending = make([]string, 0), make([]string, 0)
+	seen := make(map[string]bool)
+	for _, c := range required {
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		switch byContext[c] {
+		case "success":
+		case "failure", "error":
+			failed = append(failed, c)
+		default:
+			pending = append(pending, c)
+		}
+	}
+
+	switch {
+	case len(failed) > 0:
+		return "failure", failed, pending
+	case len(pending) > 0:
+		return "pending", failed, pending
+	}
+	return "success", failed, pending
+}
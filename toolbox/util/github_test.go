@@ -1,10 +1,17 @@
 package util
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/google/go-github/github"
 )
 
 func TestLastReleases(t *testing.T) {
@@ -227,3 +234,190 @@ func TestAddQuerySearchIssues(t *testing.T) {
 		}
 	}
 }
+
+func TestParsePRFromCommit(t *testing.T) {
+	messages := []string{
+		"Merge pull request #53422 from liggitt/automated-cherry-pick-of-#53233-upstream-release-1.8\n\nAutomated cherry pick of #53233",
+		"Merge pull request #52602 from liggitt/automated-cherry-pick-of-#48394-#43152-upstream-release-1.7",
+		"Merge pull request #53097 from m1093782566/ipvs-test\n\nRun IPVS proxier UTs everywhere",
+		"Merge pull request #53098 from someone/automated-cherry-pick-of-#53233-upstream-release-1.8",
+		"Fix typo in comment",
+	}
+	var commits []*github.RepositoryCommit
+	for _, m := range messages {
+		commits = append(commits, &github.RepositoryCommit{Commit: &github.Commit{Message: github.String(m)}})
+	}
+
+	want := []int{53233, 48394, 43152, 53097}
+	got, err := ParsePRFromCommit(commits)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Number of PRs was incorrect, want: %v, got: %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("PR was incorrect, want: %d, got: %d", want[i], got[i])
+		}
+	}
+}
+
+func TestGetFileContent(t *testing.T) {
+	tables := []struct {
+		owner  string
+		repo   string
+		path   string
+		ref    string
+		prefix string
+		exist  bool
+	}{
+		{"kubernetes", "kubernetes", "Godeps/Godeps.json", "v1.8.0", "{\n\t\"ImportPath\": \"k8s.io/kubernetes\"", true},
+		{"kubernetes", "kubernetes", "api/openapi-spec/swagger.json", "v1.8.0", "{\n  \"swagger\": \"2.0\"", true},
+		{"kubernetes", "kubernetes", "go.mod", "v1.8.0", "", false},
+	}
+
+	githubToken := os.Getenv("GITHUB_TOKEN")
+	c := NewClient(githubToken)
+
+	for _, table := range tables {
+		content, err := c.GetFileContent(table.owner, table.repo, table.path, table.ref)
+		if (err == nil) != table.exist {
+			t.Errorf("%v %v: Existence check failed, want: %v, got error: %v", table.path, table.ref, table.exist, err)
+		}
+		if !table.exist && !os.IsNotExist(err) {
+			t.Errorf("%v %v: Expected not exist error, got: %v", table.path, table.ref, err)
+		}
+		if table.exist && !strings.HasPrefix(string(content), table.prefix) {
+			t.Errorf("%v %v: Content was incorrect, want prefix: %q", table.path, table.ref, table.prefix)
+		}
+	}
+}
+
+func TestGetIssue(t *testing.T) {
+	tables := []struct {
+		number int
+		title  string
+		exist  bool
+	}{
+		{53233, "Remove containers of deleted pods once all containers have exited", true},
+		{999999999, "", false},
+	}
+
+	githubToken := os.Getenv("GITHUB_TOKEN")
+	c := NewClient(githubToken)
+
+	for _, table := range tables {
+		i, err := c.GetIssue("kubernetes", "kubernetes", table.number)
+		if (err == nil) != table.exist {
+			t.Errorf("%d: Existence check failed, want: %v, got error: %v", table.number, table.exist, err)
+		}
+		if table.exist && err == nil && *i.Title != table.title {
+			t.Errorf("%d: Title was incorrect, want: %s, got: %s", table.number, table.title, *i.Title)
+		}
+	}
+}
+
+func TestReviewsApproved(t *testing.T) {
+	review := func(user, state string) *github.PullRequestReview {
+		return &github.PullRequestReview{User: &github.User{Login: github.String(user)}, State: github.String(state)}
+	}
+	tables := []struct {
+		reviews []*github.PullRequestReview
+		want    bool
+	}{
+		{nil, false},
+		{[]*github.PullRequestReview{review("a", "COMMENTED")}, false},
+		{[]*github.PullRequestReview{review("a", "APPROVED"), review("a", "COMMENTED")}, true},
+		{[]*github.PullRequestReview{review("a", "APPROVED"), review("b", "CHANGES_REQUESTED")}, false},
+		{[]*github.PullRequestReview{review("b", "CHANGES_REQUESTED"), review("a", "APPROVED"), review("b", "APPROVED")}, true},
+		{[]*github.PullRequestReview{review("a", "APPROVED"), review("a", "DISMISSED")}, false},
+	}
+
+	for i, table := range tables {
+		if got := ReviewsApproved(table.reviews); got != table.want {
+			t.Errorf("%d: Approval was incorrect, want: %v, got: %v", i, table.want, got)
+		}
+	}
+}
+
+func TestChecksState(t *testing.T) {
+	status := func(context, state string) github.RepoStatus {
+		return github.RepoStatus{Context: github.String(context), State: github.String(state)}
+	}
+	// Latest statuses first
+	statuses := []github.RepoStatus{
+		status("unit", "success"),
+		status("e2e", "failure"),
+		status("verify", "pending"),
+		status("e2e", "success"),
+	}
+
+	tables := []struct {
+		required []string
+		state    string
+		failed   []string
+		pending  []string
+	}{
+		{[]string{"unit"}, "success", []string{}, []string{}},
+		{[]string{"unit", "verify"}, "pending", []string{}, []string{"verify"}},
+		{[]string{"unit", "bazel"}, "pending", []string{}, []string{"bazel"}},
+		{[]string{"e2e", "verify"}, "failure", []string{"e2e"}, []string{"verify"}},
+		{nil, "failure", []string{"e2e"}, []string{"verify"}},
+	}
+
+	for _, table := range tables {
+		state, failed, pending := ChecksState(statuses, table.required)
+		if state != table.state || !reflect.DeepEqual(failed, table.failed) || !reflect.DeepEqual(pending, table.pending) {
+			t.Errorf("%v: Checks state was incorrect, want: %v %v %v, got: %v %v %v",
+				table.required, table.state, table.failed, table.pending, state, failed, pending)
+		}
+	}
+}
+
+// newTestClient returns a client of the Github API served by input handler, and a function
+// closing the server.
+func newTestClient(handler http.Handler) (*GithubClient, func()) {
+	server := httptest.NewServer(handler)
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return &GithubClient{client: client}, server.Close
+}
+
+func TestListAllStatuses(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/kubernetes/kubernetes/commits/abc/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `{"state": "failure", "statuses": [{"context": "e2e", "state": "failure"}]}`)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=2&per_page=100>; rel="next"`, r.Host, r.URL.Path))
+		fmt.Fprint(w, `{"state": "failure", "statuses": [{"context": "unit", "state": "success"}]}`)
+	})
+	g, closeServer := newTestClient(mux)
+	defer closeServer()
+
+	statuses, err := g.ListAllStatuses("kubernetes", "kubernetes", "abc")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var contexts []string
+	for _, s := range statuses {
+		contexts = append(contexts, s.GetContext())
+	}
+	if want := []string{"unit", "e2e"}; !reflect.DeepEqual(contexts, want) {
+		t.Errorf("Status contexts were incorrect, want: %v, got: %v", want, contexts)
+	}
+}
+
+func TestRequiredStatusChecksNotFound(t *testing.T) {
+	g, closeServer := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	}))
+	defer closeServer()
+
+	checks, err := g.RequiredStatusChecks("kubernetes", "kubernetes", "master")
+	if err != nil || len(checks) != 0 {
+		t.Errorf("Required checks were incorrect, want none, got: %v (%v)", checks, err)
+	}
+}
@@ -15,6 +15,7 @@
 package util
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 )
@@ -50,3 +51,174 @@ func IsVer(version string, t string) bool {
 	re, _ := regexp.Compile(m[t])
 	return re.MatchString(version)
 }
+
+// Git runs a git command inside the repository at dir and returns the trimmed output.
+// On failure the git output is included in the returned error.
+func Git(dir string, arg ...string) (string, error) {
+	out, err := Shell("git", append([]string{"-C", dir}, arg...)...)
+	out = strings.TrimSpace(out)
+	if err != nil {
+		return out, fmt.Errorf("git %s: %v: %s", strings.Join(arg, " "), err, out)
+	}
+	return out, nil
+}
+
+// RevParse resolves input revision (branch, tag, commit, "tag^{}", etc.) to an object SHA in
+// the repository at dir.
+func RevParse(dir, rev string) (string, error) {
+	return Git(dir, "rev-parse", "--verify", "--quiet", rev)
+}
+
+// CommitMessages lists the messages of the commits reachable from end but not from start in the
+// repository at dir, newest first, like "git log start..end". The refs can be on different
+// branches, e.g. "v1.7.8" and "v1.8.2".
+func CommitMessages(dir, start, end string) ([]string, error) {
+	out, err := Git(dir, "log", "--format=%B%x00", start+".."+end)
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]string, 0)
+	for _, m := range strings.Split(out, "\x00") {
+		if m = strings.TrimSpace(m); m != "" {
+			messages = append(messages, m)
+		}
+	}
+	return messages, nil
+}
+
+// ReleaseTagMessage generates the annotation message for a release tag, mirroring the one
+// anago's git_tag step writes. For example:
+//
+//     "v1.9.0-alpha.1" - "Kubernetes alpha release v1.9.0-alpha.1"
+//     "v1.8.0-beta.0"  - "Kubernetes beta release v1.8.0-beta.0"
+//     "v1.8.2"         - "Kubernetes release v1.8.2"
+func ReleaseTagMessage(tag string) string {
+	re, _ := regexp.Compile("^v[0-9]+\\.[0-9]+\\.[0-9]+-(alpha|beta|rc)")
+	if label := re.FindStringSubmatch(tag); label != nil {
+		return fmt.Sprintf("Kubernetes %s release %s", label[1], tag)
+	}
+	return fmt.Sprintf("Kubernetes release %s", tag)
+}
+
+// CreateTag creates an annotated tag on commit in the repository at dir. If sign is true, the
+// tag is GPG-signed with the default key of the committer instead. The function fails if the
+// tag already exists locally.
+func CreateTag(dir, tag, commit, message string, sign bool) error {
+	if _, err := RevParse(dir, "refs/tags/"+tag); err == nil {
+		return fmt.Errorf("tag %s already exists", tag)
+	}
+
+	mode := "-a"
+	if sign {
+		mode = "-s"
+	}
+	if _, err := Git(dir, "tag", mode, "-m", message, tag, commit); err != nil {
+		return fmt.Errorf("failed to create tag %s on %s: %v", tag, commit, err)
+	}
+	return nil
+}
+
+// VerifyTag checks that tag exists in the repository at dir, is an annotated (or signed) tag
+// rather than a lightweight one, and points at the same commit as object.
+func VerifyTag(dir, tag, object string) error {
+	t, err := Git(dir, "cat-file", "-t", "refs/tags/"+tag)
+	if err != nil {
+		return fmt.Errorf("tag %s not found: %v", tag, err)
+	}
+	if t != "tag" {
+		return fmt.Errorf("tag %s is not annotated (object type %s)", tag, t)
+	}
+
+	got, err := RevParse(dir, "refs/tags/"+tag+"^{commit}")
+	if err != nil {
+		return fmt.Errorf("failed to resolve tag %s: %v", tag, err)
+	}
+	want, err := RevParse(dir, object+"^{commit}")
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %v", object, err)
+	}
+	if got != want {
+		return fmt.Errorf("tag %s points at %s, want %s (%s)", tag, got, want, object)
+	}
+	return nil
+}
+
+// RemoteTags lists tags on remote for the repository at dir, and returns a tag-indexed map of
+// the commits they point at. Annotated tags are peeled to their commits.
+func RemoteTags(dir, remote string) (map[string]string, error) {
+	out, err := Git(dir, "ls-remote", "--tags", remote)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags on %s: %v", remote, err)
+	}
+
+	tags := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		name := strings.TrimPrefix(fields[1], "refs/tags/")
+		if strings.HasSuffix(name, "^{}") {
+			// Peeled entry always follows the tag object entry and wins
+			tags[strings.TrimSuffix(name, "^{}")] = fields[0]
+		} else if _, ok := tags[name]; !ok {
+			tags[name] = fields[0]
+		}
+	}
+	return tags, nil
+}
+
+// CheckTagCollisions makes sure none of the input local tags would collide with tags on remote.
+// A remote tag with the same name collides unless it points at the same commit as the local
+// one, in which case pushing it again is a no-op.
+func CheckTagCollisions(dir, remote string, tags ...string) error {
+	remoteTags, err := RemoteTags(dir, remote)
+	if err != nil {
+		return err
+	}
+
+	var collisions []string
+	for _, tag := range tags {
+		sha, ok := remoteTags[tag]
+		if !ok {
+			continue
+		}
+		local, err := RevParse(dir, "refs/tags/"+tag+"^{commit}")
+		if err != nil {
+			return fmt.Errorf("failed to resolve local tag %s: %v", tag, err)
+		}
+		if local != sha {
+			collisions = append(collisions, fmt.Sprintf("%s (remote %s, local %s)", tag, sha, local))
+		}
+	}
+	if len(collisions) > 0 {
+		return fmt.Errorf("tags already exist on %s: %s", remote, strings.Join(collisions, ", "))
+	}
+	return nil
+}
+
+// PushAtomic pushes input refs (branches and tags) to remote in a single atomic push, so
+// either all refs are updated on the remote or none are. Tags are checked for collisions with
+// the remote before pushing. If dryRun is true, git only reports what would be pushed.
+func PushAtomic(dir, remote string, dryRun bool, branches, tags []string) error {
+	if err := CheckTagCollisions(dir, remote, tags...); err != nil {
+		return err
+	}
+
+	args := []string{"push", "--atomic"}
+	if dryRun {
+		args = append(args, "--dry-run")
+	}
+	args = append(args, remote)
+	for _, b := range branches {
+		args = append(args, "refs/heads/"+b+":refs/heads/"+b)
+	}
+	for _, t := range tags {
+		args = append(args, "refs/tags/"+t+":refs/tags/"+t)
+	}
+
+	if _, err := Git(dir, args...); err != nil {
+		return fmt.Errorf("failed to push to %s: %v", remote, err)
+	}
+	return nil
+}
@@ -1,6 +1,12 @@
 package util
 
-import "testing"
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/release/toolbox/util/testutil"
+)
 
 func TestIsVer(t *testing.T) {
 	tables := []struct {
@@ -23,3 +29,160 @@ func TestIsVer(t *testing.T) {
 		}
 	}
 }
+
+func TestReleaseTagMessage(t *testing.T) {
+	tables := []struct {
+		tag     string
+		message string
+	}{
+		{"v1.9.0-alpha.1", "Kubernetes alpha release v1.9.0-alpha.1"},
+		{"v1.8.0-beta.0", "Kubernetes beta release v1.8.0-beta.0"},
+		{"v1.8.0-rc.1", "Kubernetes rc release v1.8.0-rc.1"},
+		{"v1.8.2", "Kubernetes release v1.8.2"},
+	}
+
+	for _, table := range tables {
+		if m := ReleaseTagMessage(table.tag); m != table.message {
+			t.Errorf("%v: Tag message was incorrect, want: %q, got: %q", table.tag, table.message, m)
+		}
+	}
+}
+
+func TestCreateVerifyTag(t *testing.T) {
+	dir, cleanup := testutil.SetupGitRepos(t)
+	defer cleanup()
+
+	if err := CreateTag(dir, "v1.8.0", "HEAD", ReleaseTagMessage("v1.8.0"), false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := CreateTag(dir, "v1.8.0", "HEAD", ReleaseTagMessage("v1.8.0"), false); err == nil {
+		t.Errorf("Expected error creating existing tag")
+	}
+	if err := VerifyTag(dir, "v1.8.0", "master"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Tag no longer points at the branch head once the branch moves
+	if _, err := Git(dir, "commit", "--allow-empty", "-m", "second commit"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := VerifyTag(dir, "v1.8.0", "master"); err == nil {
+		t.Errorf("Expected error verifying tag against moved branch")
+	}
+	if err := VerifyTag(dir, "v1.8.0", "master~1"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Lightweight tags are rejected
+	if _, err := Git(dir, "tag", "v1.8.1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := VerifyTag(dir, "v1.8.1", "master"); err == nil {
+		t.Errorf("Expected error verifying lightweight tag")
+	}
+	if err := VerifyTag(dir, "v9.9.9", "master"); err == nil {
+		t.Errorf("Expected error verifying missing tag")
+	}
+}
+
+func TestPushAtomic(t *testing.T) {
+	dir, cleanup := testutil.SetupGitRepos(t)
+	defer cleanup()
+
+	if _, err := Git(dir, "branch", "release-1.8"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := CreateTag(dir, "v1.8.0-beta.0", "release-1.8", ReleaseTagMessage("v1.8.0-beta.0"), false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Dry run must not update the remote
+	if err := PushAtomic(dir, "origin", true, []string{"release-1.8"}, []string{"v1.8.0-beta.0"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	tags, err := RemoteTags(dir, "origin")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(tags) != 0 {
+		t.Errorf("Dry run pushed tags: %v", tags)
+	}
+
+	if err := PushAtomic(dir, "origin", false, []string{"release-1.8"}, []string{"v1.8.0-beta.0"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	tags, err = RemoteTags(dir, "origin")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	head, _ := RevParse(dir, "release-1.8")
+	if tags["v1.8.0-beta.0"] != head {
+		t.Errorf("Remote tag was incorrect, want: %s, got: %s", head, tags["v1.8.0-beta.0"])
+	}
+
+	// Pushing the same tag again is not a collision
+	if err := CheckTagCollisions(dir, "origin", "v1.8.0-beta.0"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Recreate the tag on another commit locally, which must collide with the remote
+	if _, err := Git(dir, "commit", "--allow-empty", "-m", "second commit"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := Git(dir, "tag", "-d", "v1.8.0-beta.0"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := CreateTag(dir, "v1.8.0-beta.0", "master", "moved", false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := PushAtomic(dir, "origin", false, []string{"master"}, []string{"v1.8.0-beta.0"}); err == nil {
+		t.Errorf("Expected tag collision error")
+	}
+	// The branch update must not have gone through either
+	remoteMaster, _ := Git(dir, "ls-remote", "origin", "refs/heads/master")
+	localMaster, _ := RevParse(dir, "master")
+	if strings.HasPrefix(remoteMaster, localMaster) {
+		t.Errorf("Branch was pushed despite tag collision")
+	}
+}
+
+func TestCommitMessages(t *testing.T) {
+	dir, cleanup := testutil.SetupGitRepos(t)
+	defer cleanup()
+
+	cmds := [][]string{
+		{"tag", "v1.7.0"},
+		{"checkout", "-b", "release-1.7"},
+		{"commit", "--allow-empty", "-m", "On release-1.7"},
+		{"checkout", "master"},
+		{"commit", "--allow-empty", "-m", "First on master\n\nWith a body"},
+		{"commit", "--allow-empty", "-m", "Second on master"},
+	}
+	for _, c := range cmds {
+		if _, err := Git(dir, c...); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	tables := []struct {
+		start, end string
+		want       []string
+	}{
+		{"release-1.7", "master", []string{"Second on master", "First on master\n\nWith a body"}},
+		{"master", "release-1.7", []string{"On release-1.7"}},
+		{"v1.7.0", "v1.7.0", []string{}},
+	}
+	for _, table := range tables {
+		got, err := CommitMessages(dir, table.start, table.end)
+		if err != nil {
+			t.Errorf("%s..%s: Unexpected error: %v", table.start, table.end, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, table.want) {
+			t.Errorf("%s..%s: Messages were incorrect, want: %q, got: %q", table.start, table.end, table.want, got)
+		}
+	}
+	if _, err := CommitMessages(dir, "v9.9.9", "master"); err == nil {
+		t.Errorf("Expected error for missing ref")
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2017 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testutil contains helpers shared by the tests of the toolbox commands.
+package testutil
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// SetupGitRepos creates a bare repository acting as the remote, and a clone of it with one
+// commit on master pushed, for the tests of git operations. The git identity is set in the
+// environment. It returns the clone directory and a cleanup function, which removes the
+// repositories and restores the environment.
+func SetupGitRepos(t *testing.T) (string, func()) {
+	root, err := ioutil.TempDir("", "gitlib_test")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	env := make(map[string]*string)
+	for k, v := range map[string]string{
+		"GIT_AUTHOR_NAME":     "Test",
+		"GIT_AUTHOR_EMAIL":    "test@example.com",
+		"GIT_COMMITTER_NAME":  "Test",
+		"GIT_COMMITTER_EMAIL": "test@example.com",
+	} {
+		if old, ok := os.LookupEnv(k); ok {
+			env[k] = &old
+		} else {
+			env[k] = nil
+		}
+		os.Setenv(k, v)
+	}
+	cleanup := func() {
+		os.RemoveAll(root)
+		for k, v := range env {
+			if v == nil {
+				os.Unsetenv(k)
+			} else {
+				os.Setenv(k, *v)
+			}
+		}
+	}
+
+	bare := filepath.Join(root, "remote.git")
+	clone := filepath.Join(root, "clone")
+	cmds := [][]string{
+		{root, "init", "--bare", bare},
+		{root, "clone", bare, clone},
+		{clone, "checkout", "-b", "master"},
+		{clone, "commit", "--allow-empty", "-m", "initial commit"},
+		{clone, "push", "origin", "master"},
+	}
+	for _, c := range cmds {
+		if out, err := exec.Command("git", append([]string{"-C", c[0]}, c[1:]...)...).CombinedOutput(); err != nil {
+			cleanup()
+			t.Fatalf("Unexpected error: %v: %s", err, out)
+		}
+	}
+	return clone, cleanup
+}